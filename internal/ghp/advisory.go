@@ -0,0 +1,281 @@
+package ghp
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// osvEntry is the subset of the OSV schema the advisory check needs.
+// See https://ossf.github.io/osv-schema/.
+type osvEntry struct {
+	ID       string `json:"id"`
+	Summary  string `json:"summary"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Introduced   string `json:"introduced"`
+				Fixed        string `json:"fixed"`
+				LastAffected string `json:"last_affected"`
+			} `json:"events"`
+		} `json:"ranges"`
+		Versions []string `json:"versions"`
+	} `json:"affected"`
+}
+
+// advisoryDB indexes an offline OSV dump by ecosystem and package name.
+type advisoryDB struct {
+	byPkg  map[string][]*osvEntry
+	newest map[string]string // Highest version any advisory mentions per package
+}
+
+// loadAdvisoryDB reads OSV entries from a JSON file, holding one entry or an
+// array of them, or from every .json file below a directory, which is how
+// the OSV bulk exports unpack.
+func loadAdvisoryDB(root string) (*advisoryDB, error) {
+	db := &advisoryDB{byPkg: map[string][]*osvEntry{}, newest: map[string]string{}}
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(p, ".json") {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		var entries []*osvEntry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			var one osvEntry
+			if err := json.Unmarshal(data, &one); err != nil {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+			entries = []*osvEntry{&one}
+		}
+		for _, e := range entries {
+			db.add(e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func (db *advisoryDB) add(e *osvEntry) {
+	seen := map[string]bool{}
+	for _, a := range e.Affected {
+		key := advisoryKey(a.Package.Ecosystem, a.Package.Name)
+		if !seen[key] {
+			db.byPkg[key] = append(db.byPkg[key], e)
+			seen[key] = true
+		}
+
+		known := a.Versions
+		for _, r := range a.Ranges {
+			if r.Type == "GIT" {
+				continue // Events are commit hashes, not versions
+			}
+			for _, ev := range r.Events {
+				known = append(known, ev.Introduced, ev.Fixed, ev.LastAffected)
+			}
+		}
+		for _, v := range known {
+			if v != "" && v != "0" && compareVersions(v, db.newest[key]) > 0 {
+				db.newest[key] = v
+			}
+		}
+	}
+}
+
+// check flags dependencies pinned to a version an advisory marks as
+// affected, and pins that look old: Go pseudo-versions older than
+// maxAgeYears, or versions two or more majors behind the newest version the
+// database knows for the package.
+func (db *advisoryDB) check(deps []Dependency, maxAgeYears int) []DependencyFinding {
+	var findings []DependencyFinding
+	for _, d := range deps {
+		if d.Version == "" {
+			continue
+		}
+		key := advisoryKey(d.Ecosystem, d.Name)
+
+		for _, e := range db.byPkg[key] {
+			if e.affects(d) {
+				findings = append(findings, DependencyFinding{Dependency: d, Kind: findingVulnerable, ID: e.ID, Summary: e.Summary})
+			}
+		}
+
+		if t, ok := pseudoVersionTime(d.Version); ok && maxAgeYears > 0 && time.Since(t) > time.Duration(maxAgeYears)*365*24*time.Hour {
+			findings = append(findings, DependencyFinding{Dependency: d, Kind: findingOutdated,
+				Summary: fmt.Sprintf("pseudo-version from %s", t.Format("2006-01-02"))})
+			continue
+		}
+		if newest := db.newest[key]; newest != "" && majorOf(newest)-majorOf(d.Version) >= 2 {
+			findings = append(findings, DependencyFinding{Dependency: d, Kind: findingOutdated,
+				Summary: fmt.Sprintf("%s is known, pinned %s", newest, d.Version)})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Kind != findings[j].Kind {
+			return findings[i].Kind == findingVulnerable
+		}
+		return findings[i].Name < findings[j].Name
+	})
+	return findings
+}
+
+func (e *osvEntry) affects(d Dependency) bool {
+	for _, a := range e.Affected {
+		if advisoryKey(a.Package.Ecosystem, a.Package.Name) != advisoryKey(d.Ecosystem, d.Name) {
+			continue
+		}
+		for _, v := range a.Versions {
+			if compareVersions(v, d.Version) == 0 {
+				return true
+			}
+		}
+		for _, r := range a.Ranges {
+			if r.Type == "GIT" {
+				continue
+			}
+			// Events are ordered: each introduced opens a range that the
+			// next fixed or last_affected closes.
+			in := false
+			for _, ev := range r.Events {
+				switch {
+				case ev.Introduced != "":
+					in = ev.Introduced == "0" || compareVersions(d.Version, ev.Introduced) >= 0
+				case ev.Fixed != "" && in:
+					if compareVersions(d.Version, ev.Fixed) < 0 {
+						return true
+					}
+					in = false
+				case ev.LastAffected != "" && in:
+					if compareVersions(d.Version, ev.LastAffected) <= 0 {
+						return true
+					}
+					in = false
+				}
+			}
+			if in {
+				return true // Open range, no fix yet
+			}
+		}
+	}
+	return false
+}
+
+func advisoryKey(ecosystem, name string) string {
+	if ecosystem == "PyPI" {
+		name = strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+	}
+	return ecosystem + "/" + name
+}
+
+// compareVersions compares dotted versions numerically, part by part. A
+// pre-release suffix sorts before the release it precedes.
+func compareVersions(a, b string) int {
+	if b == "" {
+		if a == "" {
+			return 0
+		}
+		return 1
+	}
+	a, _, _ = strings.Cut(strings.TrimPrefix(a, "v"), "+")
+	b, _, _ = strings.Cut(strings.TrimPrefix(b, "v"), "+")
+	aMain, aPre, _ := strings.Cut(a, "-")
+	bMain, bPre, _ := strings.Cut(b, "-")
+
+	ap, bp := strings.Split(aMain, "."), strings.Split(bMain, ".")
+	for i := 0; i < len(ap) || i < len(bp); i++ {
+		var x, y string
+		if i < len(ap) {
+			x = ap[i]
+		}
+		if i < len(bp) {
+			y = bp[i]
+		}
+		if c := comparePart(x, y); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	// Pre-release identifiers compare field by field, numbers numerically,
+	// so rc.10 is newer than rc.2
+	ap, bp = strings.Split(aPre, "."), strings.Split(bPre, ".")
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		if c := comparePart(ap[i], bp[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(ap), len(bp))
+}
+
+func comparePart(x, y string) int {
+	xn, xerr := strconv.Atoi(orZero(x))
+	yn, yerr := strconv.Atoi(orZero(y))
+	if xerr == nil && yerr == nil {
+		switch {
+		case xn < yn:
+			return -1
+		case xn > yn:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(x, y)
+}
+
+func orZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+func majorOf(v string) int {
+	main, _, _ := strings.Cut(strings.TrimPrefix(v, "v"), ".")
+	n, _ := strconv.Atoi(main)
+	return n
+}
+
+var pseudoVersionRe = regexp.MustCompile(`[-.](\d{14})-[0-9a-f]{12}$`)
+
+// pseudoVersionTime returns the commit time encoded in a Go pseudo-version
+// such as v0.0.0-20190101000000-abcdef123456.
+func pseudoVersionTime(v string) (time.Time, bool) {
+	m := pseudoVersionRe.FindStringSubmatch(v)
+	if m == nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("20060102150405", m[1])
+	return t, err == nil
+}
@@ -0,0 +1,81 @@
+package ghp
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"1.9.9", "1.10.0", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.0-rc1", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc1", 1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+		{"1.0.0-rc", "1.0.0-rc.1", -1},
+		{"1.0.0-rc.1", "1.0.0-rc.1", 0},
+		{"1.0.0+build.5", "1.0.0", 0},
+		{"1.0.0-rc.1+build", "1.0.0-rc.1", 0},
+		{"1.0", "", 1},
+		{"", "1.0", -1},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAdvisoryDBMixedRanges(t *testing.T) {
+	const entry = `{
+		"id": "GHSA-test",
+		"summary": "test advisory",
+		"affected": [{
+			"package": {"ecosystem": "npm", "name": "left-pad"},
+			"ranges": [
+				{"type": "GIT", "events": [{"introduced": "0"}, {"fixed": "f3a9c2e1b4d5a6b7c8d9e0f1a2b3c4d5e6f7a8b9"}]},
+				{"type": "SEMVER", "events": [{"introduced": "1.0.0"}, {"fixed": "3.1.0"}]}
+			]
+		}]
+	}`
+	var e osvEntry
+	if err := json.Unmarshal([]byte(entry), &e); err != nil {
+		t.Fatal(err)
+	}
+	db := &advisoryDB{byPkg: map[string][]*osvEntry{}, newest: map[string]string{}}
+	db.add(&e)
+
+	if got := db.newest["npm/left-pad"]; got != "3.1.0" {
+		t.Errorf("newest = %q, want 3.1.0", got)
+	}
+
+	tests := []struct {
+		version string
+		want    []string // Finding kinds
+	}{
+		{"3.1.0", nil},
+		{"2.0.0", []string{findingVulnerable}},
+		{"1.0.0", []string{findingVulnerable, findingOutdated}},
+		{"0.9.0", []string{findingOutdated}},
+	}
+	for _, tt := range tests {
+		findings := db.check([]Dependency{{Ecosystem: "npm", Name: "left-pad", Version: tt.version}}, 0)
+		var kinds []string
+		for _, f := range findings {
+			kinds = append(kinds, f.Kind)
+		}
+		if !slices.Equal(kinds, tt.want) {
+			t.Errorf("check(%s) = %v, want %v", tt.version, kinds, tt.want)
+		}
+	}
+}
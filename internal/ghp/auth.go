@@ -0,0 +1,191 @@
+package ghp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const defaultGithubAPI = "https://api.github.com"
+
+// newTokenSource picks the GitHub credential from the auth config. A GitHub
+// App installation wins over a plain token; plain tokens are taken from the
+// config, then the token file, then the credential helper command.
+func newTokenSource(a Auth) (oauth2.TokenSource, error) {
+	switch {
+	case a.App.AppID != 0:
+		src, err := newAppTokenSource(a.App)
+		if err != nil {
+			return nil, fmt.Errorf("github app: %w", err)
+		}
+		return oauth2.ReuseTokenSource(nil, src), nil
+
+	case a.GithubToken != "":
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: a.GithubToken}), nil
+
+	case a.TokenFile != "":
+		b, err := os.ReadFile(filepath.Clean(a.TokenFile))
+		if err != nil {
+			return nil, fmt.Errorf("token file: %w", err)
+		}
+		return staticToken(string(b), "token file")
+
+	case a.TokenCommand != "":
+		out, err := exec.Command("sh", "-c", a.TokenCommand).Output()
+		if err != nil {
+			return nil, fmt.Errorf("token command: %w", err)
+		}
+		return staticToken(string(out), "token command")
+
+	default:
+		return nil, errors.New("no GitHub credentials configured")
+	}
+}
+
+func staticToken(raw, source string) (oauth2.TokenSource, error) {
+	token := strings.TrimSpace(raw)
+	if token == "" {
+		return nil, fmt.Errorf("%s returned an empty token", source)
+	}
+	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}), nil
+}
+
+// appTokenSource exchanges a JWT signed with the App private key for an
+// installation token. Wrapped in oauth2.ReuseTokenSource it is only called
+// again when the current installation token is about to expire.
+type appTokenSource struct {
+	appID          int64
+	installationID int64
+	apiURL         string
+	key            *rsa.PrivateKey
+	client         *http.Client
+}
+
+func newAppTokenSource(cfg GithubApp) (*appTokenSource, error) {
+	if cfg.InstallationID == 0 {
+		return nil, errors.New("missing installation_id")
+	}
+
+	b, err := os.ReadFile(filepath.Clean(cfg.PrivateKeyPath))
+	if err != nil {
+		return nil, fmt.Errorf("private key: %w", err)
+	}
+
+	key, err := parseRSAPrivateKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("private key: %w", err)
+	}
+
+	apiURL := strings.TrimSuffix(cfg.APIURL, "/")
+	if apiURL == "" {
+		apiURL = defaultGithubAPI
+	}
+
+	return &appTokenSource{
+		appID:          cfg.AppID,
+		installationID: cfg.InstallationID,
+		apiURL:         apiURL,
+		key:            key,
+		client:         &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.signJWT(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", s.apiURL, s.installationID)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("installation token: %s: %s", resp.Status, bytes.TrimSpace(b))
+	}
+
+	var out struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("installation token: %w", err)
+	}
+
+	return &oauth2.Token{AccessToken: out.Token, TokenType: "token", Expiry: out.ExpiresAt}, nil
+}
+
+// signJWT builds the RS256 App JWT. GitHub accepts at most ten minutes of
+// validity and recommends backdating iat to absorb clock drift.
+func (s *appTokenSource) signJWT(now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": fmt.Sprint(s.appID),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func parseRSAPrivateKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	// NOTE: GitHub hands out PKCS#1 keys, but a converted PKCS#8 key is fine too.
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA private key")
+	}
+
+	return key, nil
+}
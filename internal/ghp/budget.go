@@ -0,0 +1,147 @@
+package ghp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type budgetUnit struct {
+	chunk  FileChunk
+	tokens int
+	value  float64
+}
+
+// packChunks selects the most valuable units that fit in budget tokens.
+// Units larger than maxUnit are trimmed first. Every unit already taken from
+// a file halves the value of the next one, so a single large file cannot eat
+// the whole budget.
+func packChunks(units []FileChunk, tok tokenEstimator, budget, maxUnit int) []FileChunk {
+	cands := make([]budgetUnit, 0, len(units))
+	for _, c := range units {
+		c = truncateChunkTokens(c, tok, maxUnit)
+		n := tok.Estimate(c.Content)
+		if n == 0 {
+			continue
+		}
+		cands = append(cands, budgetUnit{chunk: c, tokens: n, value: unitValue(c, n)})
+	}
+
+	perFile := make(map[string]int)
+	used := 0
+	var out []FileChunk
+	for len(cands) > 0 {
+		best := -1
+		var bestValue float64
+		for i, u := range cands {
+			if used+u.tokens > budget {
+				continue
+			}
+			v := u.value / float64(int(1)<<min(perFile[u.chunk.Path], 30))
+			if best < 0 || v > bestValue {
+				best, bestValue = i, v
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		u := cands[best]
+		out = append(out, u.chunk)
+		used += u.tokens
+		perFile[u.chunk.Path]++
+		cands = slices.Delete(cands, best, best+1)
+	}
+
+	slices.SortFunc(out, func(a, b FileChunk) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return a.StartLine - b.StartLine
+	})
+
+	return out
+}
+
+// unitValue rates a unit by where it lives and how much code it carries.
+// Units under 20 tokens say little; past 400 tokens extra size adds nothing.
+func unitValue(c FileChunk, tokens int) float64 {
+	size := float64(min(tokens, 400)) / 400
+	if tokens < 20 {
+		size /= 4
+	}
+	return float64(scorePath(c.Path)) * size
+}
+
+// groupChunks merges small neighbouring units of the same file into one LLM
+// call while the group stays within maxTokens. A unit is small when it takes
+// at most a quarter of maxTokens. Zero disables grouping, and so does a
+// language without comments to mark where each unit starts.
+func groupChunks(chunks []FileChunk, tok tokenEstimator, maxTokens int) []FileChunk {
+	if maxTokens <= 0 || len(chunks) < 2 {
+		return chunks
+	}
+
+	small := maxTokens / 4
+	var out []FileChunk
+	cur := chunks[0]
+	curTokens := tok.Estimate(cur.Content)
+	curSmall := curTokens <= small
+	for _, c := range chunks[1:] {
+		n := tok.Estimate(c.Content)
+		sep, ok := commentLine(c.Language, fmt.Sprintf("... lines %d-%d: %s", c.StartLine, c.EndLine, c.Symbol))
+		if ok && curSmall && n <= small && c.Path == cur.Path && curTokens+n <= maxTokens {
+			cur.Content += "\n\n" + sep + "\n" + c.Content
+			cur.EndLine = c.EndLine
+			cur.Symbol = strings.TrimPrefix(cur.Symbol+", "+c.Symbol, ", ")
+			curTokens += n
+			continue
+		}
+		out = append(out, cur)
+		cur, curTokens, curSmall = c, n, n <= small
+	}
+
+	return append(out, cur)
+}
+
+// commentLine renders text as a line comment in lang, so separators in a
+// grouped chunk do not read as code. It fails for languages without comments.
+func commentLine(lang, text string) (string, bool) {
+	switch lang {
+	case "Python", "Ruby", "Elixir", "Shell", "YAML", "TOML":
+		return "# " + text, true
+	case "Clojure", "ClojureScript", "Racket":
+		return ";; " + text, true
+	case "HTML", "Markdown":
+		return "<!-- " + text + " -->", true
+	case "CSS":
+		return "/* " + text + " */", true
+	case "JSON", "Unknown":
+		return "", false
+	}
+	return "// " + text, true
+}
+
+// truncateChunkTokens drops trailing lines until the chunk fits in limit
+// tokens, keeping EndLine in sync with what is actually sent.
+func truncateChunkTokens(c FileChunk, tok tokenEstimator, limit int) FileChunk {
+	if limit <= 0 || tok.Estimate(c.Content) <= limit {
+		return c
+	}
+
+	lines := strings.Split(c.Content, "\n")
+	used, keep := 0, 0
+	for keep < len(lines) {
+		n := tok.Estimate(lines[keep] + "\n")
+		if used+n > limit {
+			break
+		}
+		used += n
+		keep++
+	}
+	keep = max(keep, 1)
+
+	c.Content = strings.Join(lines[:keep], "\n")
+	c.EndLine = c.StartLine + keep - 1
+	return c
+}
@@ -1,7 +1,12 @@
 package ghp
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"time"
@@ -53,3 +58,81 @@ func readCache(path string, target any, ttl time.Duration) (bool, error) {
 
 	return true, nil // Cache hit
 }
+
+// deriveCacheKey turns the configured cache passphrase into an AES-256 key.
+// An empty passphrase yields a nil key, which disables sealed caching.
+func deriveCacheKey(passphrase string) []byte {
+	if passphrase == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(passphrase))
+	return sum[:]
+}
+
+func writeSealedCache(path string, data any, key []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, gcm.Seal(nonce, nonce, b, nil), 0600)
+}
+
+func readSealedCache(path string, target any, ttl time.Duration, key []byte) (bool, error) {
+	stat, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if time.Since(stat.ModTime()) > ttl {
+		return false, nil
+	}
+
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return false, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return false, errors.New("sealed cache entry too short")
+	}
+
+	nonce, ct := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	b, err := gcm.Open(nil, nonce, ct, nil)
+	if err != nil {
+		return false, nil // Sealed with another key, treat as a miss
+	}
+
+	if err := json.Unmarshal(b, target); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,336 @@
+package ghp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BenchmarkSet is a curated list of repos with known target levels,
+// ordered from the lowest level to the highest.
+type BenchmarkSet struct {
+	Name    string      `yaml:"name"`
+	Version string      `yaml:"version"`
+	Levels  []string    `yaml:"levels"`
+	Repos   []Benchmark `yaml:"repos"`
+
+	dir string
+}
+
+// Benchmark is a GitHub repo ("owner/name") or a local fixture or clone,
+// relative to the set file.
+type Benchmark struct {
+	Repo   string `yaml:"repo"`
+	Branch string `yaml:"branch"`
+	Path   string `yaml:"path"`
+	Level  string `yaml:"level"`
+}
+
+// Calibration maps raw scores to percentiles of the benchmark set and to
+// levels. It is only valid for the provider, model, rubric and scoring
+// prompts it was fitted with.
+type Calibration struct {
+	Version    string             `json:"version"`
+	Created    time.Time          `json:"created"`
+	Provider   string             `json:"provider"`
+	Model      string             `json:"model"`
+	Rubric     string             `json:"rubric"`
+	RubricHash string             `json:"rubric_hash"`
+	Prompts    map[string]string  `json:"prompts"` // Hash of each scoring prompt by name
+	Levels     []string           `json:"levels"`
+	Thresholds []float64          `json:"thresholds"` // Raw score where each level after the first starts
+	Scores     []float64          `json:"scores"`     // Sorted raw scores of the benchmark set
+	Benchmarks []CalibrationPoint `json:"benchmarks"`
+}
+
+type CalibrationPoint struct {
+	Repo  string `json:"repo"`
+	Level string `json:"level"`
+	Score int    `json:"score"`
+}
+
+// Calibrated is a raw score placed on the calibration.
+type Calibrated struct {
+	Percentile float64
+	Level      string
+}
+
+func LoadBenchmarkSet(path string) (*BenchmarkSet, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var set BenchmarkSet
+	if err := yaml.Unmarshal(b, &set); err != nil {
+		return nil, err
+	}
+	set.dir = filepath.Dir(path)
+
+	if len(set.Levels) < 2 {
+		return nil, fmt.Errorf("benchmarks: need at least two levels, got %v", set.Levels)
+	}
+	for i, r := range set.Repos {
+		if (r.Repo == "") == (r.Path == "") {
+			return nil, fmt.Errorf("benchmarks: entry %d needs either repo or path", i+1)
+		}
+		if r.Repo != "" && !strings.Contains(r.Repo, "/") {
+			return nil, fmt.Errorf("benchmarks: repo %q is not owner/name", r.Repo)
+		}
+		if !slices.Contains(set.Levels, r.Level) {
+			return nil, fmt.Errorf("benchmarks: entry %d has unknown level %q, want one of %v", i+1, r.Level, set.Levels)
+		}
+	}
+	for _, l := range set.Levels {
+		if !slices.ContainsFunc(set.Repos, func(r Benchmark) bool { return r.Level == l }) {
+			return nil, fmt.Errorf("benchmarks: level %q has no repos", l)
+		}
+	}
+
+	return &set, nil
+}
+
+func LoadCalibration(path string) (*Calibration, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Calibration
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	if len(c.Scores) == 0 || len(c.Thresholds) != len(c.Levels)-1 {
+		return nil, fmt.Errorf("calibration %s: no scores or thresholds", path)
+	}
+
+	return &c, nil
+}
+
+// Calibrate runs the evaluation over the benchmark set and fits the
+// mapping from raw scores to percentiles and levels.
+func (s *service) Calibrate(ctx context.Context, set *BenchmarkSet) (*Calibration, error) {
+	local := &localRepos{remote: s.gh, dirs: map[string]string{}}
+	targets := make([]RepoTarget, len(set.Repos))
+	for i, b := range set.Repos {
+		if b.Path != "" {
+			dir := b.Path
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(set.dir, dir)
+			}
+			name := filepath.Base(dir)
+			if _, taken := local.dirs[localOwner+"/"+name]; taken {
+				name = fmt.Sprintf("%s-%d", name, i+1)
+			}
+			local.dirs[localOwner+"/"+name] = dir
+			targets[i] = RepoTarget{Owner: localOwner, Name: name, DefaultBranch: "HEAD"}
+			continue
+		}
+		owner, name, _ := strings.Cut(b.Repo, "/")
+		branch := b.Branch
+		if branch == "" {
+			var err error
+			branch, err = s.gh.GetDefaultBranch(ctx, owner, name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: default branch: %w", b.Repo, err)
+			}
+		}
+		targets[i] = RepoTarget{Owner: owner, Name: name, DefaultBranch: branch}
+	}
+
+	bench := *s
+	bench.gh = local
+
+	seed := s.cfg.App.SamplingSeed
+	if seed == 0 {
+		seed = 1 // Calibration runs must be reproducible
+	}
+
+	points := make([]CalibrationPoint, len(targets))
+	scored := make([]bool, len(targets))
+	errs := make([]error, len(targets))
+	wg := sync.WaitGroup{}
+	sem := make(chan struct{}, s.cfg.LLM.ParallelRequests)
+	for i := range targets {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			fmt.Printf("Calibrating on %s/%s (%s)...\n", targets[i].Owner, targets[i].Name, set.Repos[i].Level)
+			res, err := bench.evaluateRepo(ctx, targets[i], repoSeed(seed, targets[i]))
+			points[i] = CalibrationPoint{Repo: targets[i].Owner + "/" + targets[i].Name, Level: set.Repos[i].Level, Score: res.Score}
+			scored[i] = res.scored()
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+
+	var fit []CalibrationPoint
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", points[i].Repo, err)
+		}
+		if !scored[i] {
+			fmt.Printf("warn: %s has no scored chunks, left out of the calibration\n", points[i].Repo)
+			continue
+		}
+		fit = append(fit, points[i])
+	}
+	for _, l := range set.Levels {
+		if !slices.ContainsFunc(fit, func(p CalibrationPoint) bool { return p.Level == l }) {
+			return nil, fmt.Errorf("level %q has no scored benchmark", l)
+		}
+	}
+
+	c := fitCalibration(fit, set.Levels)
+	c.Created = time.Now().UTC()
+	version := set.Version
+	if version == "" {
+		version = set.Name
+	}
+	c.Version = fmt.Sprintf("%s-%s", version, c.Created.Format("20060102"))
+	c.Provider, c.Model, c.Rubric = s.cfg.LLM.Provider, s.cfg.LLM.Model, s.cfg.Scoring.rubric.Name
+	c.RubricHash, c.Prompts = s.cfg.Scoring.rubric.Hash, s.scoringPrompts()
+
+	return c, nil
+}
+
+// fitCalibration places a threshold halfway between the mean scores of
+// each pair of adjacent levels, kept non-decreasing so levels stay ordered
+// even when the benchmark means are not.
+func fitCalibration(points []CalibrationPoint, levels []string) *Calibration {
+	c := &Calibration{Levels: levels, Benchmarks: points}
+
+	means := make([]float64, len(levels))
+	for i, l := range levels {
+		var sum, n float64
+		for _, p := range points {
+			if p.Level == l {
+				sum += float64(p.Score)
+				n++
+			}
+		}
+		means[i] = sum / n
+	}
+	for i := 1; i < len(levels); i++ {
+		t := (means[i-1] + means[i]) / 2
+		if i > 1 && t < c.Thresholds[i-2] {
+			t = c.Thresholds[i-2]
+		}
+		c.Thresholds = append(c.Thresholds, t)
+	}
+
+	for _, p := range points {
+		c.Scores = append(c.Scores, float64(p.Score))
+	}
+	sort.Float64s(c.Scores)
+
+	return c
+}
+
+// Apply returns the mid-rank percentile of the score among the benchmark
+// scores and the level whose band it falls in.
+func (c *Calibration) Apply(score int) Calibrated {
+	v := float64(score)
+	below := sort.SearchFloat64s(c.Scores, v)
+	equal := sort.SearchFloat64s(c.Scores, v+0.5) - below
+	pct := 100 * (float64(below) + float64(equal)/2) / float64(len(c.Scores))
+
+	level := c.Levels[0]
+	for i, t := range c.Thresholds {
+		if v >= t {
+			level = c.Levels[i+1]
+		}
+	}
+	return Calibrated{Percentile: pct, Level: level}
+}
+
+// scoringPrompts are the hashes of the prompts chunk scores depend on: the
+// review prompt and its language overlays.
+func (s *service) scoringPrompts() map[string]string {
+	hashes := map[string]string{s.repoPrompt.Name: s.repoPrompt.Hash}
+	for _, p := range s.langGuides {
+		hashes[p.Name] = p.Hash
+	}
+	return hashes
+}
+
+// mismatches lists what differs between the setup the calibration was
+// fitted with and the one of s; reports are only comparable when empty.
+func (c *Calibration) mismatches(s *service) []string {
+	var diff []string
+	if c.Provider != s.cfg.LLM.Provider || c.Model != s.cfg.LLM.Model {
+		diff = append(diff, fmt.Sprintf("model (%s %s)", s.cfg.LLM.Provider, s.cfg.LLM.Model))
+	}
+	rubric := s.cfg.Scoring.rubric
+	if c.Rubric != rubric.Name || c.RubricHash != rubric.Hash {
+		diff = append(diff, fmt.Sprintf("rubric (%s %s)", rubric.Name, rubric.Hash))
+	}
+	current := s.scoringPrompts()
+	var names []string
+	for name := range current {
+		names = append(names, name)
+	}
+	for name := range c.Prompts {
+		if _, ok := current[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if c.Prompts[name] != current[name] {
+			diff = append(diff, name)
+		}
+	}
+	return diff
+}
+
+// scored reports whether any chunk of the repo got an LLM score; repos
+// without one have a score of 0 that says nothing about the code.
+func (r RepoResult) scored() bool {
+	return r.Chunks > 0 && len(r.Dimensions) > 0
+}
+
+// applyCalibration places every scored repo on the calibration and
+// estimates the profile level from the median repo score.
+func (s *service) applyCalibration(c *Calibration, results []RepoResult, meta *ReportMeta) {
+	var scores []int
+	for i := range results {
+		if !results[i].scored() {
+			continue
+		}
+		cal := c.Apply(results[i].Score)
+		results[i].Calibrated = &cal
+		scores = append(scores, results[i].Score)
+	}
+
+	meta.Calibration = fmt.Sprintf("%s (%s %s, %s rubric)", c.Version, c.Provider, c.Model, c.Rubric)
+	if diff := c.mismatches(s); len(diff) > 0 {
+		meta.Calibration += ", not comparable with this run, which differs in " + strings.Join(diff, ", ")
+	}
+	if len(scores) == 0 {
+		return
+	}
+	slices.Sort(scores)
+	est := c.Apply(scores[len(scores)/2])
+	meta.Estimate = &est
+}
+
+func (c *Calibration) Save(path string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
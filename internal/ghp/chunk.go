@@ -0,0 +1,203 @@
+package ghp
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	windowLines    = 60
+	maxWindowLines = 2 * windowLines
+)
+
+// chunkerFunc splits a source file into reviewable units.
+type chunkerFunc func(path string, src []byte) ([]FileChunk, error)
+
+// chunkers maps a guessLang language to its chunker. Languages without an
+// entry, or files a chunker cannot handle, fall back to chunkWindows.
+var chunkers = map[string]chunkerFunc{}
+
+func registerChunker(lang string, fn chunkerFunc) {
+	chunkers[lang] = fn
+}
+
+func init() {
+	registerChunker("Go", chunkGo)
+	registerChunker("Python", chunkPython)
+	for _, lang := range []string{"TypeScript", "JavaScript"} {
+		registerChunker(lang, chunkBraces(jsLikeSyntax))
+	}
+	for _, lang := range []string{"Java", "Rust", "C#", "C++", "Kotlin", "Swift", "PHP", "Dart"} {
+		registerChunker(lang, chunkBraces(cLikeSyntax))
+	}
+}
+
+// chunkFile splits a file with the chunker registered for its language.
+func chunkFile(path string, src []byte) []FileChunk {
+	if fn, ok := chunkers[guessLang(path)]; ok {
+		chunks, err := fn(path, src)
+		if err == nil && len(chunks) > 0 {
+			return chunks
+		}
+	}
+	return chunkWindows(path, src)
+}
+
+// chunkWindows cuts a file into windows of about windowLines lines. A window
+// is extended up to maxWindowLines to end where brace depth is zero and the
+// next line is not indented, so it rarely splits a block in half.
+func chunkWindows(path string, src []byte) []FileChunk {
+	lines := strings.Split(strings.TrimRight(string(src), "\n"), "\n")
+
+	var chunks []FileChunk
+	start, depth := 0, 0
+	for i, l := range lines {
+		depth += strings.Count(l, "{") + strings.Count(l, "(") - strings.Count(l, "}") - strings.Count(l, ")")
+		size := i - start + 1
+		last := i == len(lines)-1
+		atBoundary := depth <= 0 && (last || indentOf(lines[i+1]) == 0)
+		if last || (size >= windowLines && atBoundary) || size >= maxWindowLines {
+			content := strings.Join(lines[start:i+1], "\n")
+			chunks = append(chunks, newChunk(path, []byte(content), start+1, ""))
+			start, depth = i+1, 0
+		}
+	}
+
+	return chunks
+}
+
+// chunkGo splits a Go source file along its top-level declarations. Functions
+// and methods are the unit; type declarations are kept as units of their own.
+// Imports, package clause and var/const blocks are left out.
+func chunkGo(path string, src []byte) ([]FileChunk, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks []FileChunk
+	for _, decl := range f.Decls {
+		var symbol string
+		var start token.Pos
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			symbol = goFuncSymbol(d)
+			start = d.Pos()
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			symbol = goTypeSymbol(d)
+			start = d.Pos()
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		default:
+			continue
+		}
+
+		from := fset.Position(start)
+		to := fset.Position(decl.End())
+		chunks = append(chunks, newChunk(path, src[from.Offset:to.Offset], from.Line, symbol))
+	}
+
+	return chunks, nil
+}
+
+func newChunk(path string, content []byte, startLine int, symbol string) FileChunk {
+	text := strings.TrimRight(string(content), "\n")
+	return FileChunk{
+		Path:      path,
+		StartLine: startLine,
+		EndLine:   startLine + strings.Count(text, "\n"),
+		Content:   text,
+		Language:  guessLang(path),
+		Symbol:    symbol,
+	}
+}
+
+// truncateChunk cuts the content to whole lines within maxBytes, keeping
+// EndLine in sync with what is actually sent. A first line longer than
+// maxBytes is cut at a rune boundary.
+func truncateChunk(c FileChunk, maxBytes int) FileChunk {
+	if maxBytes <= 0 || len(c.Content) <= maxBytes {
+		return c
+	}
+	cut := strings.LastIndexByte(c.Content[:maxBytes+1], '\n')
+	if cut <= 0 {
+		cut = maxBytes
+		for cut > 0 && !utf8.RuneStart(c.Content[cut]) {
+			cut--
+		}
+	}
+	c.Content = strings.TrimRight(c.Content[:cut], "\n")
+	c.EndLine = c.StartLine + strings.Count(c.Content, "\n")
+	return c
+}
+
+// largestChunks keeps the n longest chunks, in their original order.
+func largestChunks(chunks []FileChunk, n int) []FileChunk {
+	if n <= 0 || len(chunks) <= n {
+		return chunks
+	}
+
+	idx := make([]int, len(chunks))
+	for i := range idx {
+		idx[i] = i
+	}
+	slices.SortStableFunc(idx, func(a, b int) int {
+		return chunkLines(chunks[b]) - chunkLines(chunks[a])
+	})
+	idx = idx[:n]
+	slices.Sort(idx)
+
+	out := make([]FileChunk, 0, n)
+	for _, i := range idx {
+		out = append(out, chunks[i])
+	}
+	return out
+}
+
+func chunkLines(c FileChunk) int {
+	return c.EndLine - c.StartLine + 1
+}
+
+func goFuncSymbol(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return d.Name.Name
+	}
+	return "(" + goRecvType(d.Recv.List[0].Type) + ")." + d.Name.Name
+}
+
+func goRecvType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + goRecvType(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return goRecvType(t.X)
+	case *ast.IndexListExpr:
+		return goRecvType(t.X)
+	default:
+		return "?"
+	}
+}
+
+func goTypeSymbol(d *ast.GenDecl) string {
+	var names []string
+	for _, spec := range d.Specs {
+		if ts, ok := spec.(*ast.TypeSpec); ok {
+			names = append(names, ts.Name.Name)
+		}
+	}
+	return "type " + strings.Join(names, ", ")
+}
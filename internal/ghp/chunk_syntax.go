@@ -0,0 +1,291 @@
+package ghp
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// braceSyntax describes the lexical bits the brace chunker must skip over so
+// braces inside strings and comments are not counted.
+type braceSyntax struct {
+	singleQuoteStrings bool // '...' is a string, not a char literal or lifetime
+	backtickStrings    bool
+}
+
+var (
+	cLikeSyntax  = braceSyntax{}
+	jsLikeSyntax = braceSyntax{singleQuoteStrings: true, backtickStrings: true}
+)
+
+var (
+	containerRe = regexp.MustCompile(`\b(class|interface|trait|impl|enum|struct|namespace|module|mod|object|record)(?:<[^{]*?>)?\s+([A-Za-z_$][\w$]*)`)
+	implForRe   = regexp.MustCompile(`\bimpl\b.*\bfor\s+([A-Za-z_]\w*)`)
+	importRe    = regexp.MustCompile(`^(import|use|export)\b\s*(type\s*)?$`)
+	callableRe  = regexp.MustCompile(`([A-Za-z_$][\w$]*)\s*(?:<[^<>()]*>)?\s*\(`)
+	bindingRe   = regexp.MustCompile(`\b(?:const|let|var|val)\s+([A-Za-z_$][\w$]*)`)
+)
+
+var notSymbols = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "function": true,
+	"return": true, "match": true, "fn": true, "func": true, "fun": true,
+}
+
+type braceBlock struct {
+	headerStart int
+	open        int
+	close       int
+	children    []*braceBlock
+}
+
+// chunkBraces returns a chunker for brace-delimited languages. Top-level
+// blocks are units; class-like containers are split into their members.
+func chunkBraces(syn braceSyntax) chunkerFunc {
+	return func(path string, src []byte) ([]FileChunk, error) {
+		blocks, err := scanBraces(string(src), syn)
+		if err != nil {
+			return nil, err
+		}
+
+		lines := lineStarts(src)
+		var chunks []FileChunk
+		var walk func(bs []*braceBlock, prefix string)
+		walk = func(bs []*braceBlock, prefix string) {
+			for _, b := range bs {
+				header := string(src[b.headerStart:b.open])
+				if importRe.MatchString(strings.TrimSpace(header)) {
+					continue // import { a, b } and friends
+				}
+				name := braceSymbol(header)
+				if containerRe.MatchString(header) && len(b.children) > 0 {
+					walk(b.children, prefix+name+".")
+					continue
+				}
+				chunks = append(chunks, newChunk(path, src[b.headerStart:b.close+1], lineAt(lines, b.headerStart), prefix+name))
+			}
+		}
+		walk(blocks, "")
+
+		return chunks, nil
+	}
+}
+
+// scanBraces builds the tree of brace blocks, skipping strings and comments.
+// Each block records where its declaration header starts: right after the
+// previous ';', '{' or '}' at any depth.
+func scanBraces(src string, syn braceSyntax) ([]*braceBlock, error) {
+	root := &braceBlock{}
+	stack := []*braceBlock{root}
+	stmtStart := -1
+
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if stmtStart < 0 && !strings.ContainsRune(" \t\r\n{};", rune(c)) {
+			stmtStart = i
+		}
+
+		switch {
+		case strings.HasPrefix(src[i:], "//"):
+			i = skipUntil(src, i, "\n") - 1
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, errors.New("unterminated block comment")
+			}
+			i += end + 3
+		case c == '"', c == '`' && syn.backtickStrings:
+			i = skipString(src, i, c)
+		case c == '\'':
+			if syn.singleQuoteStrings {
+				i = skipString(src, i, c)
+			} else if j := charLiteralEnd(src, i); j > 0 {
+				i = j
+			}
+		case c == '{':
+			if stmtStart < 0 {
+				stmtStart = i
+			}
+			b := &braceBlock{headerStart: stmtStart, open: i}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, b)
+			stack = append(stack, b)
+			stmtStart = -1
+		case c == '}':
+			if len(stack) == 1 {
+				return nil, errors.New("unbalanced braces")
+			}
+			stack[len(stack)-1].close = i
+			stack = stack[:len(stack)-1]
+			stmtStart = -1
+		case c == ';':
+			stmtStart = -1
+		}
+	}
+
+	if len(stack) != 1 {
+		return nil, errors.New("unbalanced braces")
+	}
+	return root.children, nil
+}
+
+func skipUntil(src string, i int, term string) int {
+	j := strings.Index(src[i:], term)
+	if j < 0 {
+		return len(src)
+	}
+	return i + j + len(term)
+}
+
+// skipString returns the index of the closing quote of the string at i.
+func skipString(src string, i int, quote byte) int {
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case quote:
+			return j
+		case '\n':
+			if quote != '`' {
+				return j // Unterminated, resync at the end of the line
+			}
+		}
+	}
+	return len(src) - 1
+}
+
+// charLiteralEnd recognizes 'x' and '\n' style literals, so Rust lifetimes
+// and generics such as <'a> are left alone. It returns 0 when i is not one.
+func charLiteralEnd(src string, i int) int {
+	if i+2 < len(src) && src[i+1] != '\\' && src[i+2] == '\'' {
+		return i + 2
+	}
+	if i+1 < len(src) && src[i+1] == '\\' {
+		if j := strings.IndexByte(src[i+2:min(len(src), i+12)], '\''); j >= 0 {
+			return i + 2 + j
+		}
+	}
+	return 0
+}
+
+func braceSymbol(header string) string {
+	if m := implForRe.FindStringSubmatch(header); m != nil {
+		return m[1]
+	}
+	if m := containerRe.FindStringSubmatch(header); m != nil {
+		return m[2]
+	}
+	for _, m := range callableRe.FindAllStringSubmatch(header, -1) {
+		if !notSymbols[m[1]] {
+			return m[1]
+		}
+	}
+	if m := bindingRe.FindStringSubmatch(header); m != nil {
+		return m[1]
+	}
+	return firstLine(header)
+}
+
+var pyDefRe = regexp.MustCompile(`^(\s*)(?:async\s+def|def|class)\s+([A-Za-z_]\w*)`)
+
+// chunkPython splits Python sources into top-level functions and classes,
+// using indentation. Classes with methods are split into their methods.
+// Decorators and comments right above a definition belong to it.
+func chunkPython(path string, src []byte) ([]FileChunk, error) {
+	lines := strings.Split(string(src), "\n")
+	return pythonUnits(path, lines, 0, len(lines), "", ""), nil
+}
+
+func pythonUnits(path string, lines []string, from, to int, indent, prefix string) []FileChunk {
+	var chunks []FileChunk
+	for i := from; i < to; i++ {
+		m := pyDefRe.FindStringSubmatch(lines[i])
+		if m == nil || m[1] != indent {
+			continue
+		}
+
+		start := i
+		for start > from && isPyPreamble(lines[start-1], indent) {
+			start--
+		}
+
+		end := i + 1
+		inDoc := false
+		for ; end < to; end++ {
+			l := lines[end]
+			if strings.Count(l, `"""`)%2 == 1 || strings.Count(l, `'''`)%2 == 1 {
+				inDoc = !inDoc
+			}
+			if inDoc || strings.TrimSpace(l) == "" {
+				continue
+			}
+			if indentOf(l) <= len(indent) && !strings.HasPrefix(strings.TrimSpace(l), ")") {
+				break
+			}
+		}
+		for end > i+1 && strings.TrimSpace(lines[end-1]) == "" {
+			end--
+		}
+
+		name := m[2]
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), "class") {
+			if members := pythonUnits(path, lines, i+1, end, bodyIndent(lines, i+1, end), prefix+name+"."); len(members) > 0 {
+				chunks = append(chunks, members...)
+				i = end - 1
+				continue
+			}
+		}
+
+		chunks = append(chunks, newChunk(path, []byte(strings.Join(lines[start:end], "\n")), start+1, prefix+name))
+		i = end - 1
+	}
+	return chunks
+}
+
+func isPyPreamble(line, indent string) bool {
+	t := strings.TrimSpace(line)
+	return strings.HasPrefix(line, indent) && indentOf(line) == len(indent) && (strings.HasPrefix(t, "@") || strings.HasPrefix(t, "#"))
+}
+
+func bodyIndent(lines []string, from, to int) string {
+	for i := from; i < to; i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			return lines[i][:indentOf(lines[i])]
+		}
+	}
+	return ""
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// firstLine returns the first line of s that is not a comment, shortened.
+func firstLine(s string) string {
+	var l string
+	for _, line := range strings.Split(s, "\n") {
+		l = strings.TrimSpace(line)
+		if l != "" && !strings.HasPrefix(l, "//") && !strings.HasPrefix(l, "/*") && !strings.HasPrefix(l, "*") && !strings.HasPrefix(l, "#") {
+			break
+		}
+	}
+	if len(l) > 40 {
+		l = l[:40]
+	}
+	return l
+}
+
+// lineStarts returns the byte offset of every line, for offset to line lookups.
+func lineStarts(src []byte) []int {
+	starts := []int{0}
+	for i, c := range src {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+func lineAt(starts []int, offset int) int {
+	return sort.Search(len(starts), func(i int) bool { return starts[i] > offset })
+}
@@ -0,0 +1,75 @@
+package ghp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanBraces(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		syn     braceSyntax
+		want    string // Block tree as nested braces
+		wantErr string
+	}{
+		{"function", "void f() { if (x) { y(); } }", cLikeSyntax, "{{}}", ""},
+		{"siblings", "int a() { }\nint b() { }", cLikeSyntax, "{}{}", ""},
+		{"line comment", "f() { // } {\n}", cLikeSyntax, "{}", ""},
+		{"block comment", "f() { /* }\n{ */ }", cLikeSyntax, "{}", ""},
+		{"unterminated block comment", "f() { /* }", cLikeSyntax, "", "unterminated block comment"},
+		{"string", `f() { s = "}{"; }`, cLikeSyntax, "{}", ""},
+		{"escaped quote", `f() { s = "\"}"; }`, cLikeSyntax, "{}", ""},
+		{"char literal", "f() { c = '}'; }", cLikeSyntax, "{}", ""},
+		{"escaped char literal", `f() { c = '\''; d = '{'; }`, cLikeSyntax, "{}", ""},
+		{"rust lifetime", "fn f<'a>(x: &'a str) { if y { } }", cLikeSyntax, "{{}}", ""},
+		{"unterminated string resyncs", "f() { s = \"{\n}", cLikeSyntax, "{}", ""},
+		{"single quote string", "f() { s = 'a}b'; }", jsLikeSyntax, "{}", ""},
+		{"raw string", "f() { s = `}\n{`; }", jsLikeSyntax, "{}", ""},
+		{"template literal", "f() { s = `${a} {`; }", jsLikeSyntax, "{}", ""},
+		{"backtick without raw strings", "f() { s = `{`; } }", cLikeSyntax, "{{}}", ""},
+		{"class members", "class A { m() { } n() { } }", jsLikeSyntax, "{{}{}}", ""},
+		{"extra close", "f() { } }", cLikeSyntax, "", "unbalanced braces"},
+		{"unclosed", "f() { {", cLikeSyntax, "", "unbalanced braces"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, err := scanBraces(tt.src, tt.syn)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := braceShape(blocks); got != tt.want {
+				t.Errorf("blocks = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanBracesHeader(t *testing.T) {
+	src := "import x;\n/* doc */\nclass A {\n  int n;\n  void m() { }\n}"
+	blocks, err := scanBraces(src, cLikeSyntax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := src[blocks[0].headerStart:blocks[0].open]; !strings.HasPrefix(got, "/* doc */") {
+		t.Errorf("class header = %q", got)
+	}
+	m := blocks[0].children[0]
+	if got := strings.TrimSpace(src[m.headerStart:m.open]); got != "void m()" {
+		t.Errorf("method header = %q, want %q", got, "void m()")
+	}
+}
+
+func braceShape(bs []*braceBlock) string {
+	var b strings.Builder
+	for _, blk := range bs {
+		b.WriteString("{" + braceShape(blk.children) + "}")
+	}
+	return b.String()
+}
@@ -1,24 +1,52 @@
 package ghp
 
 import (
+	"fmt"
 	"os"
+	"slices"
 
 	"gopkg.in/yaml.v3"
 )
 
 type App struct {
-	PromptPath       string `yaml:"prompt_path"`
+	PromptPath string `yaml:"prompt_path"`
+	// PromptsDir overrides embedded prompts: a file there replaces the
+	// prompt at the same path under prompts/, e.g. lang/go.txt.
+	PromptsDir       string `yaml:"prompts_dir"`
 	OutDir           string `yaml:"out_dir"`
 	ReposLimit       int    `yaml:"repos_limit"`
 	ChunksPerRepo    int    `yaml:"chunks_per_repo"`
 	MaxChunkBytes    int    `yaml:"max_chunk_bytes"`
+	ChunksPerFile    int    `yaml:"chunks_per_file"`
+	Sampling         string `yaml:"sampling"`
+	SamplingSeed     int64  `yaml:"sampling_seed"`
 	IncludePinned    bool   `yaml:"include_pinned"`
 	IncludeNonPinned bool   `yaml:"include_non_pinned"`
 	ExcludeForks     bool   `yaml:"exclude_forks"`
+	HistoryCommits   int    `yaml:"history_commits"`
+	GithubRetries    int    `yaml:"github_retries"`
+	// Calibration is a file written by "ghp calibrate"; reports then show
+	// an estimated level.
+	Calibration string `yaml:"calibration"`
+	// Private repositories are only profiled with include_private set and
+	// an explicit "owner/name" entry in private_allowlist.
+	IncludePrivate   bool     `yaml:"include_private"`
+	PrivateAllowlist []string `yaml:"private_allowlist"`
 }
 
 type Auth struct {
-	GithubToken string `yaml:"github_token"`
+	GithubToken  string    `yaml:"github_token"`
+	TokenFile    string    `yaml:"token_file"`
+	TokenCommand string    `yaml:"token_command"`
+	App          GithubApp `yaml:"github_app"`
+	CacheKey     string    `yaml:"cache_key"`
+}
+
+type GithubApp struct {
+	AppID          int64  `yaml:"app_id"`
+	InstallationID int64  `yaml:"installation_id"`
+	PrivateKeyPath string `yaml:"private_key_path"`
+	APIURL         string `yaml:"api_url"`
 }
 
 type LLM struct {
@@ -30,12 +58,38 @@ type LLM struct {
 	Temperature       float32 `yaml:"temperature"`
 	RequestsPerMinute int     `yaml:"requests_per_minute"`
 	ParallelRequests  int     `yaml:"parallel_requests"`
+	// TokenBudget caps the code tokens sent per repository. When set it
+	// replaces chunks_per_file and max_chunk_bytes; zero keeps them.
+	TokenBudget    int `yaml:"token_budget"`
+	MaxChunkTokens int `yaml:"max_chunk_tokens"`
+	GroupTokens    int `yaml:"group_tokens"`
+}
+
+// Static configures the deterministic Go analysis. Weight is the share of
+// the static score in the final repo score, from 0 (report only) to 1.
+// Factors weigh the parts of the static score; unlisted ones weigh 1.
+type Static struct {
+	Enabled bool               `yaml:"enabled"`
+	Weight  float64            `yaml:"weight"`
+	Factors map[string]float64 `yaml:"factors"`
+}
+
+// Deps configures the dependency manifest analysis. AdvisoryDB points to
+// an offline OSV dump, a JSON file or a directory of them; without it only
+// the dependency lists are reported.
+type Deps struct {
+	Enabled        bool   `yaml:"enabled"`
+	AdvisoryDB     string `yaml:"advisory_db"`
+	MaxPinAgeYears int    `yaml:"max_pin_age_years"`
 }
 
 type Config struct {
-	App  App  `yaml:"app"`
-	Auth Auth `yaml:"auth"`
-	LLM  LLM  `yaml:"llm"`
+	App     App     `yaml:"app"`
+	Auth    Auth    `yaml:"auth"`
+	LLM     LLM     `yaml:"llm"`
+	Static  Static  `yaml:"static"`
+	Deps    Deps    `yaml:"dependencies"`
+	Scoring Scoring `yaml:"scoring"`
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -44,7 +98,9 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
-	var c Config
+	// NOTE: Defaults where zero is a valid setting go in before decoding,
+	// absent keys leave them as they are.
+	c := Config{Scoring: Scoring{TrimRatio: defaultTrimRatio}}
 	if err := yaml.Unmarshal(b, &c); err != nil {
 		return nil, err
 	}
@@ -57,7 +113,47 @@ func LoadConfig(path string) (*Config, error) {
 		c.LLM.RequestsPerMinute = 60
 	}
 
-	if c.Auth.GithubToken == "" {
+	if c.LLM.TokenBudget > 0 && c.LLM.MaxChunkTokens <= 0 {
+		c.LLM.MaxChunkTokens = 600
+	}
+
+	if err := c.Static.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := c.Scoring.validate(); err != nil {
+		return nil, err
+	}
+
+	if c.App.Sampling == "" {
+		c.App.Sampling = samplingScore
+	}
+	if !slices.Contains(samplingStrategies, c.App.Sampling) {
+		return nil, fmt.Errorf("unknown sampling strategy %q, want one of %v", c.App.Sampling, samplingStrategies)
+	}
+
+	if c.Deps.MaxPinAgeYears <= 0 {
+		c.Deps.MaxPinAgeYears = 3
+	}
+
+	if c.App.ChunksPerFile <= 0 {
+		c.App.ChunksPerFile = 3
+	}
+
+	if c.App.HistoryCommits <= 0 {
+		c.App.HistoryCommits = 100
+	}
+
+	if c.App.GithubRetries <= 0 {
+		c.App.GithubRetries = 3
+	}
+
+	if c.Auth.CacheKey == "" {
+		c.Auth.CacheKey = os.Getenv("GHP_CACHE_KEY")
+	}
+
+	noCredentials := c.Auth.TokenFile == "" && c.Auth.TokenCommand == "" && c.Auth.App.AppID == 0
+	if c.Auth.GithubToken == "" && noCredentials {
 		c.Auth.GithubToken = os.Getenv("GITHUB_TOKEN")
 	}
 
@@ -0,0 +1,358 @@
+package ghp
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Dependency is a direct dependency declared in a manifest.
+type Dependency struct {
+	Ecosystem string // OSV ecosystem name: Go, npm, PyPI, crates.io, Maven
+	Name      string
+	Version   string // As pinned, with range operators stripped; empty when unpinned
+	Manifest  string
+}
+
+// DependencyFinding flags a dependency as vulnerable or outdated.
+type DependencyFinding struct {
+	Dependency
+	Kind    string // findingVulnerable or findingOutdated
+	ID      string
+	Summary string
+}
+
+// DependencyReport lists the direct dependencies of a repo and what the
+// advisory check found about them.
+type DependencyReport struct {
+	Manifests []string
+	Deps      []Dependency
+	Findings  []DependencyFinding
+}
+
+const (
+	findingVulnerable = "vulnerable"
+	findingOutdated   = "outdated"
+)
+
+const maxManifests = 20
+
+type manifestParser func(manifest string, src []byte) []Dependency
+
+var manifestParsers = map[string]manifestParser{
+	"go.mod":           parseGoMod,
+	"package.json":     parsePackageJSON,
+	"requirements.txt": parseRequirements,
+	"pyproject.toml":   parsePyproject,
+	"cargo.toml":       parseCargoToml,
+	"pom.xml":          parsePom,
+}
+
+// isManifest reports whether the file is a dependency manifest handled by
+// the dependency stage rather than sampled for the LLM.
+func isManifest(p string) bool {
+	_, ok := manifestParsers[strings.ToLower(path.Base(p))]
+	return ok
+}
+
+// evaluateDependencies parses the manifests found in the tree and checks
+// their direct dependencies against the offline advisory database.
+func (s *service) evaluateDependencies(ctx context.Context, repo RepoTarget, sha string, entries []TreeEntry) DependencyReport {
+	var rep DependencyReport
+	for _, e := range entries {
+		parse, ok := manifestParsers[strings.ToLower(path.Base(e.Path))]
+		if !ok {
+			continue
+		}
+		if len(rep.Manifests) == maxManifests {
+			break
+		}
+
+		data, err := s.gh.ReadFile(ctx, repo.Owner, repo.Name, repo.DefaultBranch, e.Path, sha)
+		if err != nil {
+			fmt.Printf("warn: could not read %s in %s/%s: %v\n", e.Path, repo.Owner, repo.Name, err)
+			continue
+		}
+		rep.Manifests = append(rep.Manifests, e.Path)
+		rep.Deps = append(rep.Deps, parse(e.Path, data)...)
+	}
+
+	if s.advisories != nil {
+		rep.Findings = s.advisories.check(rep.Deps, s.cfg.Deps.MaxPinAgeYears)
+	}
+
+	return rep
+}
+
+// go.mod
+
+func parseGoMod(manifest string, src []byte) []Dependency {
+	var deps []Dependency
+	inBlock := false
+	for _, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.Contains(line, "// indirect") {
+			continue
+		}
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require "))
+		case !inBlock:
+			continue
+		}
+
+		f := strings.Fields(line)
+		if len(f) == 2 {
+			deps = append(deps, Dependency{Ecosystem: "Go", Name: f[0], Version: f[1], Manifest: manifest})
+		}
+	}
+	return deps
+}
+
+// package.json
+
+func parsePackageJSON(manifest string, src []byte) []Dependency {
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(src, &pkg); err != nil {
+		return nil
+	}
+
+	var deps []Dependency
+	for _, m := range []map[string]string{pkg.Dependencies, pkg.DevDependencies} {
+		for name, spec := range m {
+			deps = append(deps, Dependency{Ecosystem: "npm", Name: name, Version: pinnedVersion(spec), Manifest: manifest})
+		}
+	}
+	sortDeps(deps)
+	return deps
+}
+
+// requirements.txt and pyproject.toml
+
+var pyReqRe = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(?:\[[^\]]*\])?\s*(.*)$`)
+
+func parseRequirements(manifest string, src []byte) []Dependency {
+	var deps []Dependency
+	for _, line := range strings.Split(string(src), "\n") {
+		if d, ok := parsePyRequirement(manifest, line); ok {
+			deps = append(deps, d)
+		}
+	}
+	return deps
+}
+
+func parsePyRequirement(manifest, line string) (Dependency, bool) {
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = line[:i]
+	}
+	if i := strings.Index(line, ";"); i >= 0 {
+		line = line[:i] // Environment markers
+	}
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "-") {
+		return Dependency{}, false
+	}
+
+	m := pyReqRe.FindStringSubmatch(line)
+	if m == nil {
+		return Dependency{}, false
+	}
+	return Dependency{Ecosystem: "PyPI", Name: strings.ToLower(m[1]), Version: pinnedVersion(m[2]), Manifest: manifest}, true
+}
+
+func parsePyproject(manifest string, src []byte) []Dependency {
+	var deps []Dependency
+	for _, t := range tomlTables(src) {
+		switch t.name {
+		case "project":
+			for _, req := range tomlStringArray(t.values["dependencies"]) {
+				if d, ok := parsePyRequirement(manifest, req); ok {
+					deps = append(deps, d)
+				}
+			}
+		case "tool.poetry.dependencies", "tool.poetry.dev-dependencies", "tool.poetry.group.dev.dependencies":
+			for _, k := range t.keys {
+				if k == "python" {
+					continue
+				}
+				deps = append(deps, Dependency{Ecosystem: "PyPI", Name: strings.ToLower(k), Version: pinnedVersion(tomlVersion(t.values[k])), Manifest: manifest})
+			}
+		}
+	}
+	return deps
+}
+
+// Cargo.toml
+
+func parseCargoToml(manifest string, src []byte) []Dependency {
+	var deps []Dependency
+	for _, t := range tomlTables(src) {
+		if t.name != "dependencies" && t.name != "dev-dependencies" && t.name != "build-dependencies" {
+			continue
+		}
+		for _, k := range t.keys {
+			deps = append(deps, Dependency{Ecosystem: "crates.io", Name: k, Version: pinnedVersion(tomlVersion(t.values[k])), Manifest: manifest})
+		}
+	}
+	return deps
+}
+
+// pom.xml
+
+func parsePom(manifest string, src []byte) []Dependency {
+	var pom struct {
+		Properties struct {
+			Entries []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:"properties"`
+		Dependencies []struct {
+			GroupID    string `xml:"groupId"`
+			ArtifactID string `xml:"artifactId"`
+			Version    string `xml:"version"`
+		} `xml:"dependencies>dependency"`
+	}
+	if err := xml.Unmarshal(src, &pom); err != nil {
+		return nil
+	}
+
+	props := map[string]string{}
+	for _, p := range pom.Properties.Entries {
+		props[p.XMLName.Local] = strings.TrimSpace(p.Value)
+	}
+
+	var deps []Dependency
+	for _, d := range pom.Dependencies {
+		v := strings.TrimSpace(d.Version)
+		if strings.HasPrefix(v, "${") && strings.HasSuffix(v, "}") {
+			v = props[v[2:len(v)-1]]
+		}
+		deps = append(deps, Dependency{Ecosystem: "Maven", Name: d.GroupID + ":" + d.ArtifactID, Version: pinnedVersion(v), Manifest: manifest})
+	}
+	return deps
+}
+
+// Helpers
+
+var versionRe = regexp.MustCompile(`v?\d+(\.[0-9A-Za-z]+)*(-[0-9A-Za-z.+-]+)?`)
+
+// pinnedVersion extracts the version a spec resolves to at its lower bound:
+// "^1.2.3", "~=1.2", "==1.2.3" and "1.2.3" all pin something checkable,
+// while "*", ">=1" style open ranges and URLs are reported as unpinned.
+func pinnedVersion(spec string) string {
+	spec = strings.TrimSpace(spec)
+	for _, op := range []string{"==", "~=", "^", "~", "="} {
+		if strings.HasPrefix(spec, op) {
+			return versionRe.FindString(strings.TrimSpace(spec[len(op):]))
+		}
+	}
+	if spec == "" || strings.ContainsAny(spec, "<>*|:/ ") {
+		return ""
+	}
+	if v := versionRe.FindString(spec); v == spec {
+		return v
+	}
+	return ""
+}
+
+type tomlTable struct {
+	name   string
+	keys   []string
+	values map[string]string
+}
+
+// tomlTables is a minimal reader for the manifest subset of TOML: tables
+// with key = value pairs, where arrays may span lines.
+func tomlTables(src []byte) []tomlTable {
+	var tables []tomlTable
+	cur := &tomlTable{values: map[string]string{}}
+	var pendingKey string
+	var pending strings.Builder
+
+	for _, line := range strings.Split(string(src), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if pendingKey != "" {
+			pending.WriteString(" " + trimmed)
+			if strings.HasPrefix(trimmed, "]") || strings.HasSuffix(trimmed, "]") {
+				cur.values[pendingKey] = pending.String()
+				pendingKey = ""
+			}
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") && !strings.Contains(trimmed, "=") {
+			tables = append(tables, *cur)
+			name := strings.Trim(trimmed, "[] ")
+			cur = &tomlTable{name: name, values: map[string]string{}}
+			continue
+		}
+
+		k, v, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			continue
+		}
+		k = strings.Trim(strings.TrimSpace(k), `"'`)
+		v = strings.TrimSpace(v)
+		cur.keys = append(cur.keys, k)
+		if strings.HasPrefix(v, "[") && strings.Count(v, "[") > strings.Count(v, "]") {
+			pendingKey = k
+			pending.Reset()
+			pending.WriteString(v)
+			continue
+		}
+		cur.values[k] = v
+	}
+	if pendingKey != "" {
+		cur.values[pendingKey] = pending.String()
+	}
+	return append(tables, *cur)
+}
+
+var tomlStringRe = regexp.MustCompile(`"([^"]*)"|'([^']*)'`)
+
+func tomlStringArray(v string) []string {
+	var out []string
+	for _, m := range tomlStringRe.FindAllStringSubmatch(v, -1) {
+		out = append(out, m[1]+m[2])
+	}
+	return out
+}
+
+var tomlVersionRe = regexp.MustCompile(`version\s*=\s*["']([^"']*)["']`)
+
+// tomlVersion returns the version of a plain string value or of an inline
+// table such as { version = "1.0", features = [...] }.
+func tomlVersion(v string) string {
+	if strings.HasPrefix(v, "{") {
+		if m := tomlVersionRe.FindStringSubmatch(v); m != nil {
+			return m[1]
+		}
+		return ""
+	}
+	return strings.Trim(v, `"'`)
+}
+
+func sortDeps(deps []Dependency) {
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+}
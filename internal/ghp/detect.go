@@ -0,0 +1,176 @@
+package ghp
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	reasonGenerated = "generated"
+	reasonVendored  = "vendored"
+	reasonMinified  = "minified"
+	reasonLockfile  = "lockfile"
+)
+
+var lockfiles = map[string]bool{
+	"package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true, "npm-shrinkwrap.json": true,
+	"cargo.lock": true, "poetry.lock": true, "pipfile.lock": true, "composer.lock": true,
+	"gemfile.lock": true, "go.sum": true, "mix.lock": true, "pubspec.lock": true,
+}
+
+var generatedPathRe = regexp.MustCompile(`(_gen\.go|_generated\.go|\.pb\.go|\.pb\.gw\.go|_pb2\.py|_pb2_grpc\.py|\.pb\.(cc|h)|\.g\.dart|\.freezed\.dart)$|(^|/)zz_generated[^/]*$`)
+
+// generatedHeaderRe matches the standard Go marker (https://go.dev/s/generatedcode)
+// and the comment-prefixed @generated banner most other generators write.
+// A bare "DO NOT EDIT" is too common in hand-written code to count.
+var generatedHeaderRe = regexp.MustCompile(`(?m)^(// Code generated .* DO NOT EDIT\.\r?$|\s*(//|#|/\*|\*|--)\s*@generated\b)`)
+
+// excludedByPath tells, from the path alone, whether a file was not written by
+// hand. It returns the reason, or "" when the file looks authored.
+func excludedByPath(p string) string {
+	l := strings.ToLower(p)
+	switch {
+	case lockfiles[filepath.Base(l)]:
+		return reasonLockfile
+	case strings.HasSuffix(l, ".min.js"), strings.HasSuffix(l, ".min.css"), strings.HasSuffix(l, ".bundle.js"):
+		return reasonMinified
+	case generatedPathRe.MatchString(l):
+		return reasonGenerated
+	case strings.HasPrefix(l, "vendor/"), strings.HasPrefix(l, "third_party/"), strings.HasPrefix(l, "node_modules/"):
+		return reasonVendored
+	}
+	return ""
+}
+
+// excludedByContent looks at the head of a file for generator banners and at
+// line lengths for minified or bundled output.
+func excludedByContent(data []byte) string {
+	head := data
+	if len(head) > 2048 {
+		head = head[:2048]
+	}
+	if generatedHeaderRe.Match(head) {
+		return reasonGenerated
+	}
+
+	var lines, longest, total int
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for sc.Scan() {
+		n := len(sc.Bytes())
+		lines++
+		total += n
+		longest = max(longest, n)
+	}
+	if lines == 0 {
+		return ""
+	}
+	if longest > 1000 || (lines > 5 && total/lines > 200) {
+		return reasonMinified
+	}
+	return ""
+}
+
+type linguistRule struct {
+	re        *regexp.Regexp
+	generated *bool
+	vendored  *bool
+}
+
+// linguistRules holds the linguist-generated and linguist-vendored rules of a
+// .gitattributes file, in file order.
+type linguistRules []linguistRule
+
+func parseGitattributes(data []byte) linguistRules {
+	var rules linguistRules
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasSuffix(fields[0], "/") {
+			continue
+		}
+
+		rule := linguistRule{re: gitattrPattern(fields[0])}
+		for _, attr := range fields[1:] {
+			switch name, val := linguistAttr(attr); name {
+			case "linguist-generated":
+				rule.generated = &val
+			case "linguist-vendored":
+				rule.vendored = &val
+			}
+		}
+		if rule.generated != nil || rule.vendored != nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// match returns the reason the rules exclude p, or "". Later rules override
+// earlier ones, as in git.
+func (r linguistRules) match(p string) string {
+	var generated, vendored bool
+	for _, rule := range r {
+		if !rule.re.MatchString(p) {
+			continue
+		}
+		if rule.generated != nil {
+			generated = *rule.generated
+		}
+		if rule.vendored != nil {
+			vendored = *rule.vendored
+		}
+	}
+	switch {
+	case generated:
+		return reasonGenerated
+	case vendored:
+		return reasonVendored
+	}
+	return ""
+}
+
+func linguistAttr(attr string) (string, bool) {
+	if strings.HasPrefix(attr, "-") || strings.HasPrefix(attr, "!") {
+		return attr[1:], false
+	}
+	name, val, ok := strings.Cut(attr, "=")
+	if !ok {
+		return name, true
+	}
+	return name, val != "false"
+}
+
+// gitattrPattern translates a .gitattributes glob into a regexp. Patterns
+// without a slash match the base name at any depth.
+func gitattrPattern(pat string) *regexp.Regexp {
+	anchored := strings.Contains(strings.TrimSuffix(pat, "/"), "/")
+	pat = strings.TrimPrefix(pat, "/")
+
+	var b strings.Builder
+	for i := 0; i < len(pat); i++ {
+		switch {
+		case strings.HasPrefix(pat[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pat[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pat[i] == '*':
+			b.WriteString("[^/]*")
+		case pat[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(pat[i : i+1]))
+		}
+	}
+
+	prefix := "^"
+	if !anchored {
+		prefix = "^(.*/)?"
+	}
+	return regexp.MustCompile(prefix + b.String() + "$")
+}
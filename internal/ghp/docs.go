@@ -0,0 +1,157 @@
+package ghp
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DocsMetrics is the documentation review of a repo. Each dimension takes
+// the best score across the reviewed files, since a README often covers
+// setup while architecture lives in its own document.
+type DocsMetrics struct {
+	Files        []string
+	Setup        int
+	Usage        int
+	Architecture int
+	Score        int // 0-5, mean of the three dimensions
+	Notes        []string
+	Rated        bool // False when no file could be reviewed
+}
+
+type docsReview struct {
+	Setup        int      `json:"setup"`
+	Usage        int      `json:"usage"`
+	Architecture int      `json:"architecture"`
+	Notes        []string `json:"notes"`
+}
+
+const docFilesReviewed = 4
+
+var docExts = map[string]bool{".md": true, ".markdown": true, ".rst": true, ".adoc": true, ".txt": true, "": true}
+
+// Root files that are documents but not documentation.
+var notDocs = []string{"license", "licence", "copying", "changelog", "changes", "history", "code_of_conduct", "notice", "authors", "codeowners"}
+
+// docPaths picks the root README first, then other root documents and
+// the first level of docs/ or doc/, smallest depth first.
+func docPaths(entries []TreeEntry) []string {
+	var readme string
+	var others []string
+	for _, e := range entries {
+		l := strings.ToLower(e.Path)
+		dir, base := path.Split(l)
+		ext := path.Ext(base)
+		if !docExts[ext] {
+			continue
+		}
+		switch dir {
+		case "":
+			if strings.HasPrefix(base, "readme") {
+				if readme == "" || ext == ".md" {
+					readme = e.Path
+				}
+				continue
+			}
+			if ext == "" || isNotDoc(base) {
+				continue
+			}
+		case "docs/", "doc/":
+			if ext == "" || ext == ".txt" {
+				continue
+			}
+		default:
+			continue
+		}
+		others = append(others, e.Path)
+	}
+
+	sort.SliceStable(others, func(i, j int) bool {
+		return strings.Count(others[i], "/") < strings.Count(others[j], "/")
+	})
+
+	var out []string
+	if readme != "" {
+		out = append(out, readme)
+	}
+	out = append(out, others...)
+	if len(out) > docFilesReviewed {
+		out = out[:docFilesReviewed]
+	}
+	return out
+}
+
+func isNotDoc(base string) bool {
+	for _, n := range notDocs {
+		if strings.HasPrefix(base, n) {
+			return true
+		}
+	}
+	return base == "contributing.md" || base == "security.md" // Covered by the health checklist
+}
+
+// evaluateDocs reviews the root README and top-level docs with the
+// documentation prompt. It also returns what was redacted from them.
+func (s *service) evaluateDocs(ctx context.Context, repo RepoTarget, sha string, entries []TreeEntry) (DocsMetrics, []Redaction) {
+	var m DocsMetrics
+	var found []Redaction
+	var review []Chunk
+	for _, p := range docPaths(entries) {
+		data, err := s.gh.ReadFile(ctx, repo.Owner, repo.Name, repo.DefaultBranch, p, sha)
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		c := truncateChunkTokens(newChunk(p, data, 1, ""), s.tok, max(s.cfg.LLM.MaxChunkTokens, 1200))
+		redacted, r := redactChunks([]FileChunk{c})
+		found = append(found, r...)
+		review = append(review, toLLMChunks(redacted)...)
+		m.Files = append(m.Files, p)
+	}
+
+	if len(review) == 0 {
+		return m, found
+	}
+
+	var reviews []docsReview
+	err := s.llm.EvaluateJSON(ctx, EvalInput{
+		Prompt: s.docsPrompt,
+		Owner:  repo.Owner,
+		Repo:   repo.Name,
+		Branch: repo.DefaultBranch,
+		Chunks: review,
+	}, &reviews)
+	if err != nil {
+		fmt.Printf("warn: docs review failed for %s/%s: %v\n", repo.Owner, repo.Name, err)
+	}
+
+	for _, r := range reviews {
+		if r.Setup == 0 && r.Usage == 0 && r.Architecture == 0 && len(r.Notes) == 0 {
+			continue // Failed call
+		}
+		m.Setup = max(m.Setup, clamp(r.Setup, 0, 5))
+		m.Usage = max(m.Usage, clamp(r.Usage, 0, 5))
+		m.Architecture = max(m.Architecture, clamp(r.Architecture, 0, 5))
+		m.Notes = append(m.Notes, r.Notes...)
+		m.Rated = true
+	}
+	m.Score = roundDiv(m.Setup+m.Usage+m.Architecture, 3)
+
+	return m, found
+}
+
+// score renders the documentation score, or n/a when the review failed.
+func (d DocsMetrics) score() string {
+	if !d.Rated {
+		return "n/a"
+	}
+	return fmt.Sprintf("%d/5", d.Score)
+}
+
+func (d DocsMetrics) dimension(v int) string {
+	if !d.Rated {
+		return "—"
+	}
+	return fmt.Sprintf("%d", v)
+}
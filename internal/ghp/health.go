@@ -0,0 +1,171 @@
+package ghp
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// HealthCheck is one item of the repository-health checklist.
+type HealthCheck struct {
+	Name    string
+	Present bool
+	Detail  string
+}
+
+// RepoHealth is the checklist computed from the tree, the Makefile and the tags.
+type RepoHealth struct {
+	Checks []HealthCheck
+	Score  int // Checks present, as a percentage
+}
+
+var ciMarkers = []struct{ prefix, name string }{
+	{".github/workflows/", "GitHub Actions"},
+	{".gitlab-ci.yml", "GitLab CI"},
+	{".circleci/", "CircleCI"},
+	{"jenkinsfile", "Jenkins"},
+	{"azure-pipelines.yml", "Azure Pipelines"},
+	{".travis.yml", "Travis CI"},
+	{"bitbucket-pipelines.yml", "Bitbucket Pipelines"},
+	{".drone.yml", "Drone"},
+	{".buildkite/", "Buildkite"},
+}
+
+var linterMarkers = map[string]string{
+	".golangci.yml": "golangci-lint", ".golangci.yaml": "golangci-lint", ".golangci.toml": "golangci-lint",
+	".eslintrc": "ESLint", ".eslintrc.js": "ESLint", ".eslintrc.json": "ESLint", ".eslintrc.cjs": "ESLint", ".eslintrc.yml": "ESLint",
+	"eslint.config.js": "ESLint", "eslint.config.mjs": "ESLint", "biome.json": "Biome",
+	".prettierrc": "Prettier", ".prettierrc.json": "Prettier", "prettier.config.js": "Prettier",
+	"ruff.toml": "Ruff", ".ruff.toml": "Ruff", ".flake8": "flake8", ".pylintrc": "Pylint", "mypy.ini": "mypy",
+	"clippy.toml": "Clippy", "rustfmt.toml": "rustfmt", ".rustfmt.toml": "rustfmt",
+	"checkstyle.xml": "Checkstyle", ".rubocop.yml": "RuboCop", ".credo.exs": "Credo",
+	".pre-commit-config.yaml": "pre-commit", ".editorconfig": "EditorConfig",
+}
+
+var makeTargetRe = regexp.MustCompile(`(?m)^([A-Za-z0-9][A-Za-z0-9_.-]*)\s*:([^=]|$)`)
+
+var versionTagRe = regexp.MustCompile(`^v?\d+(\.\d+)*([-+][0-9A-Za-z.-]+)?$`)
+
+// latestVersionTag returns the highest version among the tags, or "" when
+// none looks like a version.
+func latestVersionTag(tags []string) string {
+	latest := ""
+	for _, t := range tags {
+		if versionTagRe.MatchString(t) && (latest == "" || compareVersions(t, latest) > 0) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+// evaluateHealth builds the checklist from the tree, reading the Makefile for
+// its targets and listing tags for releases.
+func (s *service) evaluateHealth(ctx context.Context, repo RepoTarget, sha string, tree []string) RepoHealth {
+	var ci, linters, docker []string
+	var makefile, license, contributing, security, deps string
+
+	for _, p := range tree {
+		l := strings.ToLower(p)
+		base := path.Base(l)
+		for _, m := range ciMarkers {
+			if strings.HasPrefix(l, m.prefix) {
+				ci = appendUnique(ci, m.name)
+			}
+		}
+		if name, ok := linterMarkers[base]; ok {
+			linters = appendUnique(linters, name)
+		}
+		switch {
+		case base == "dockerfile", strings.HasSuffix(base, ".dockerfile"), strings.HasPrefix(base, "dockerfile."):
+			docker = appendUnique(docker, "Dockerfile")
+		case base == "docker-compose.yml", base == "docker-compose.yaml", base == "compose.yaml", base == "compose.yml":
+			docker = appendUnique(docker, "Compose")
+		}
+		if strings.Contains(l, "/") && !strings.HasPrefix(l, ".github/") && !strings.HasPrefix(l, "docs/") {
+			continue // The rest only counts at the root, .github/ or docs/
+		}
+		switch {
+		case base == "makefile" && makefile == "":
+			makefile = p
+		case strings.HasPrefix(base, "license"), strings.HasPrefix(base, "licence"), strings.HasPrefix(base, "copying"):
+			license = p
+		case strings.HasPrefix(base, "contributing"):
+			contributing = p
+		case strings.HasPrefix(base, "security"):
+			security = p
+		case base == "dependabot.yml", base == "dependabot.yaml":
+			deps = "Dependabot"
+		case base == "renovate.json", base == "renovate.json5", base == ".renovaterc", base == ".renovaterc.json":
+			deps = "Renovate"
+		}
+	}
+
+	var targets []string
+	if makefile != "" {
+		if data, err := s.gh.ReadFile(ctx, repo.Owner, repo.Name, repo.DefaultBranch, makefile, sha); err == nil {
+			for _, m := range makeTargetRe.FindAllStringSubmatch(string(data), -1) {
+				targets = appendUnique(targets, m[1])
+			}
+		}
+	}
+
+	tags, err := s.gh.ListTags(ctx, repo.Owner, repo.Name)
+	if err != nil {
+		fmt.Printf("warn: could not list tags for %s/%s: %v\n", repo.Owner, repo.Name, err)
+	}
+	release := ""
+	if len(tags) > 0 {
+		release = fmt.Sprintf("%d tags", len(tags))
+		if latest := latestVersionTag(tags); latest != "" {
+			release += ", latest " + latest
+		}
+	}
+
+	checks := []HealthCheck{
+		{Name: "CI", Present: len(ci) > 0, Detail: strings.Join(ci, ", ")},
+		{Name: "Linters", Present: len(linters) > 0, Detail: strings.Join(linters, ", ")},
+		{Name: "Docker", Present: len(docker) > 0, Detail: strings.Join(docker, ", ")},
+		{Name: "Makefile", Present: makefile != "", Detail: strings.Join(targets, ", ")},
+		{Name: "License", Present: license != "", Detail: license},
+		{Name: "Contributing", Present: contributing != "", Detail: contributing},
+		{Name: "Security policy", Present: security != "", Detail: security},
+		{Name: "Dependency updates", Present: deps != "", Detail: deps},
+		{Name: "Releases", Present: len(tags) > 0, Detail: release},
+	}
+
+	present := 0
+	for _, c := range checks {
+		if c.Present {
+			present++
+		}
+	}
+
+	return RepoHealth{Checks: checks, Score: present * 100 / len(checks)}
+}
+
+// String renders the checklist as plain lines for prompts.
+func (h RepoHealth) String() string {
+	var b strings.Builder
+	for _, c := range h.Checks {
+		state := "no"
+		if c.Present {
+			state = "yes"
+		}
+		b.WriteString(fmt.Sprintf("- %s: %s", c.Name, state))
+		if c.Detail != "" {
+			b.WriteString(" (" + c.Detail + ")")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func appendUnique(list []string, v string) []string {
+	if slices.Contains(list, v) {
+		return list
+	}
+	return append(list, v)
+}
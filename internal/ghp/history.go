@@ -0,0 +1,118 @@
+package ghp
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	recentWindow       = 90 * 24 * time.Hour
+	messageSampleLimit = 20
+)
+
+var conventionalCommitRe = regexp.MustCompile(`^(feat|fix|docs|style|refactor|perf|test|build|ci|chore|revert)(\([^)]+\))?!?: \S`)
+
+type commitRating struct {
+	Quality int      `json:"quality"`
+	Notes   []string `json:"notes"`
+}
+
+// evaluateHistory measures the commit history and has the LLM rate a sample
+// of commit subjects, redacted first. It also returns what was redacted.
+func (s *service) evaluateHistory(ctx context.Context, repo RepoTarget, sha string) (HistoryMetrics, []Redaction) {
+	commits, err := s.gh.ListCommits(ctx, repo.Owner, repo.Name, repo.DefaultBranch, sha, s.cfg.App.HistoryCommits)
+	if err != nil {
+		fmt.Printf("warn: could not list commits for %s/%s: %v\n", repo.Owner, repo.Name, err)
+		return HistoryMetrics{}, nil
+	}
+	if len(commits) == 0 {
+		return HistoryMetrics{}, nil
+	}
+
+	m := historyMetrics(commits, time.Now())
+
+	var b strings.Builder
+	for i, c := range commits {
+		if i >= messageSampleLimit {
+			break
+		}
+		b.WriteString("- " + commitSubject(c.Message) + "\n")
+	}
+	redacted, found := redactChunks([]FileChunk{newChunk("(commit messages)", []byte(b.String()), 1, "")})
+	subjects := redacted[0].Content
+
+	var rating commitRating
+	err = s.llm.EvaluateJSON(ctx, EvalInput{
+		Prompt: s.commitPrompt,
+		Data: func(Chunk) any {
+			return CommitPromptData{Owner: repo.Owner, Repo: repo.Name, Commits: subjects}
+		},
+		Owner:  repo.Owner,
+		Repo:   repo.Name,
+		Branch: repo.DefaultBranch,
+	}, &rating)
+	if err != nil {
+		fmt.Printf("warn: commit message rating failed for %s/%s: %v\n", repo.Owner, repo.Name, err)
+		return m, found
+	}
+	m.MessageQuality = clamp(rating.Quality, 0, 5)
+	m.MessageRated = true
+	m.MessageNotes = rating.Notes
+
+	return m, found
+}
+
+// messageQuality renders the commit message rating, or n/a when it failed.
+func (h HistoryMetrics) messageQuality() string {
+	if !h.MessageRated {
+		return "n/a"
+	}
+	return fmt.Sprintf("%d/5", h.MessageQuality)
+}
+
+// historyMetrics computes the deterministic part of the history metrics.
+// Commits are expected newest first, as returned by ListCommits.
+func historyMetrics(commits []CommitInfo, now time.Time) HistoryMetrics {
+	m := HistoryMetrics{Commits: len(commits)}
+	if len(commits) == 0 {
+		return m
+	}
+
+	var conventional, changed int
+	newest, oldest := commits[0].Date, commits[0].Date
+	for _, c := range commits {
+		if conventionalCommitRe.MatchString(commitSubject(c.Message)) {
+			conventional++
+		}
+		changed += c.Additions + c.Deletions
+		if now.Sub(c.Date) <= recentWindow {
+			m.RecentCommits++
+		}
+		if c.Date.After(newest) {
+			newest = c.Date
+		}
+		if c.Date.Before(oldest) {
+			oldest = c.Date
+		}
+	}
+
+	weeks := newest.Sub(oldest).Hours() / (24 * 7)
+	if weeks < 1 {
+		weeks = 1
+	}
+
+	m.CommitsPerWeek = float64(len(commits)) / weeks
+	m.ConventionalRatio = float64(conventional) / float64(len(commits))
+	m.AvgChangeSize = float64(changed) / float64(len(commits))
+	m.DaysSinceLast = int(now.Sub(newest).Hours() / 24)
+
+	return m
+}
+
+func commitSubject(msg string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(msg), "\n")
+	return strings.TrimSpace(subject)
+}
@@ -24,16 +24,51 @@ type Chunk struct {
 	EndLine   int
 	Content   string
 	Language  string
+	Symbol    string
 }
 
 type EvalInput struct {
-	Prompt string
+	Prompt *Prompt
+	// Data builds the template data for a chunk. Nil renders the prompt
+	// with the chunk's own ChunkPromptData.
+	Data   func(Chunk) any
 	Owner  string
 	Repo   string
 	Branch string
 	Chunks []Chunk
 }
 
+// system renders the system prompt for a chunk.
+func (in EvalInput) system(ch Chunk) (string, error) {
+	if in.Data != nil {
+		return in.Prompt.Render(in.Data(ch))
+	}
+	return in.Prompt.Render(in.chunkData(ch))
+}
+
+func (in EvalInput) chunkData(ch Chunk) ChunkPromptData {
+	return ChunkPromptData{
+		Owner: in.Owner, Repo: in.Repo, Branch: in.Branch,
+		Path: ch.Path, Language: ch.Language, Symbol: ch.Symbol,
+		StartLine: ch.StartLine, EndLine: ch.EndLine, Content: ch.Content,
+	}
+}
+
+// user is the user message for a chunk. It carries the chunk only when the
+// system prompt does not already embed it.
+func (in EvalInput) user(ch Chunk) string {
+	if ch.Content == "" || in.Prompt.Uses("Content") {
+		return "Respond with the JSON described in the instructions, and nothing else."
+	}
+	symbol := ch.Symbol
+	if symbol == "" {
+		symbol = "-"
+	}
+	return fmt.Sprintf(
+		`[REPO] %s/%s@%s\n[FILE] %s (%s)\n[SYMBOL] %s\n[LINES] %d-%d\n\n[CODE]\n%s\n\n[REQUIREMENTS]\n- Output STRICT JSON in the format the instructions give.\n- Base judgments ONLY on this snippet.\n- Cite concrete lines where relevant.`,
+		in.Owner, in.Repo, in.Branch, ch.Path, ch.Language, symbol, ch.StartLine, ch.EndLine, ch.Content)
+}
+
 type Client interface {
 	EvaluateJSON(ctx context.Context, in EvalInput, out any) error
 }
@@ -47,8 +82,15 @@ type openAIClient struct {
 
 func (c *openAIClient) EvaluateJSON(ctx context.Context, in EvalInput, out any) error {
 	outVal := reflect.ValueOf(out)
-	if outVal.Kind() != reflect.Ptr || outVal.Elem().Kind() != reflect.Slice {
-		return errors.New("out must be a pointer to a slice")
+	if outVal.Kind() != reflect.Ptr {
+		return errors.New("out must be a pointer")
+	}
+	if outVal.Elem().Kind() != reflect.Slice {
+		// A single result: the prompt carries all the input
+		if len(in.Chunks) == 0 {
+			return c.evalOne(ctx, in, Chunk{}, out)
+		}
+		return c.evalOne(ctx, in, in.Chunks[0], out)
 	}
 
 	sliceType := outVal.Elem().Type().Elem()
@@ -91,10 +133,11 @@ func (c *openAIClient) EvaluateJSON(ctx context.Context, in EvalInput, out any)
 }
 
 func (c *openAIClient) evalOne(ctx context.Context, in EvalInput, ch Chunk, out any) error {
-	sys := in.Prompt
-	user := fmt.Sprintf(
-		`[REPO] %s/%s@%s\n[FILE] %s (%s)\n[LINES] %d-%d\n\n[CODE]\n%s\n\n[REQUIREMENTS]\n- Output STRICT JSON: {readability, design, testing, maintainability, idiomatic, security, notes[], citations[]}\n- Base judgments ONLY on this snippet.\n- Cite concrete lines where relevant.`,
-		in.Owner, in.Repo, in.Branch, ch.Path, ch.Language, ch.StartLine, ch.EndLine, ch.Content)
+	sys, err := in.system(ch)
+	if err != nil {
+		return err
+	}
+	user := in.user(ch)
 
 	req := openai.ChatCompletionNewParams{
 		Messages: []openai.ChatCompletionMessageParamUnion{
@@ -145,10 +188,15 @@ type geminiClient struct {
 
 func (g *geminiClient) EvaluateJSON(ctx context.Context, in EvalInput, out any) error {
 	// Construir el prompt para Gemini
-	prompt := in.Prompt
+	var ch Chunk
 	if len(in.Chunks) > 0 {
-		prompt += "\n" + in.Chunks[0].Content
+		ch = in.Chunks[0]
+	}
+	prompt, err := in.system(ch)
+	if err != nil {
+		return err
 	}
+	prompt += "\n" + in.user(ch)
 
 	// Construir el request para Gemini
 	body := map[string]interface{}{
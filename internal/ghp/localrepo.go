@@ -0,0 +1,167 @@
+package ghp
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localOwner is the owner of repos served from a local directory.
+const localOwner = "local"
+
+// localRepos serves checked-out directories through the ghRepo interface,
+// so benchmark fixtures and clones can be evaluated without GitHub. Repos
+// it does not know are passed through to remote.
+type localRepos struct {
+	remote ghRepo
+	dirs   map[string]string // "local/name" -> directory
+}
+
+func (l *localRepos) dir(owner, repo string) (string, bool) {
+	d, ok := l.dirs[owner+"/"+repo]
+	return d, ok
+}
+
+func (l *localRepos) DiscoverUserRepos(ctx context.Context, handle string, opt discoverOptions) ([]RepoTarget, error) {
+	return l.remote.DiscoverUserRepos(ctx, handle, opt)
+}
+
+func (l *localRepos) GetDefaultBranch(ctx context.Context, owner, repo string) (string, error) {
+	if _, ok := l.dir(owner, repo); !ok {
+		return l.remote.GetDefaultBranch(ctx, owner, repo)
+	}
+	return "HEAD", nil
+}
+
+// GetLatestCommitSHA returns HEAD for git checkouts and a fixed marker
+// for plain directories.
+func (l *localRepos) GetLatestCommitSHA(ctx context.Context, owner, repo, ref string) (string, error) {
+	d, ok := l.dir(owner, repo)
+	if !ok {
+		return l.remote.GetLatestCommitSHA(ctx, owner, repo, ref)
+	}
+	out, err := git(ctx, d, "rev-parse", "HEAD")
+	if err != nil {
+		return "worktree", nil
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (l *localRepos) ListTree(ctx context.Context, owner, repo, ref, sha string) ([]TreeEntry, error) {
+	d, ok := l.dir(owner, repo)
+	if !ok {
+		return l.remote.ListTree(ctx, owner, repo, ref, sha)
+	}
+
+	var entries []TreeEntry
+	err := filepath.WalkDir(d, func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if e.IsDir() {
+			if e.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(d, p)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, TreeEntry{Path: filepath.ToSlash(rel), Size: int(info.Size())})
+		return nil
+	})
+	return entries, err
+}
+
+func (l *localRepos) ReadFile(ctx context.Context, owner, repo, ref, path, sha string) ([]byte, error) {
+	d, ok := l.dir(owner, repo)
+	if !ok {
+		return l.remote.ReadFile(ctx, owner, repo, ref, path, sha)
+	}
+	return os.ReadFile(filepath.Join(d, filepath.FromSlash(path)))
+}
+
+// ListCommits reads the history with git log; plain directories have none.
+func (l *localRepos) ListCommits(ctx context.Context, owner, repo, ref, sha string, limit int) ([]CommitInfo, error) {
+	d, ok := l.dir(owner, repo)
+	if !ok {
+		return l.remote.ListCommits(ctx, owner, repo, ref, sha, limit)
+	}
+
+	// Records start with \x1e and hold sha, author, date and body separated
+	// by \x1f, then the numstat lines.
+	out, err := git(ctx, d, "log", "-n", strconv.Itoa(limit), "--numstat", "--format=%x1e%H%x1f%an%x1f%aI%x1f%B%x1f")
+	if err != nil {
+		return nil, nil
+	}
+
+	var commits []CommitInfo
+	for _, rec := range strings.Split(out, "\x1e") {
+		f := strings.Split(rec, "\x1f")
+		if len(f) < 5 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, f[2])
+		c := CommitInfo{SHA: f[0], Author: f[1], Date: date, Message: strings.TrimSpace(f[3])}
+		for _, line := range strings.Split(f[4], "\n") {
+			stat := strings.Fields(line)
+			if len(stat) < 3 {
+				continue
+			}
+			add, _ := strconv.Atoi(stat[0]) // "-" for binary files
+			del, _ := strconv.Atoi(stat[1])
+			c.Additions += add
+			c.Deletions += del
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+func (l *localRepos) ListCommitFiles(ctx context.Context, owner, repo, sha string) ([]string, error) {
+	d, ok := l.dir(owner, repo)
+	if !ok {
+		return l.remote.ListCommitFiles(ctx, owner, repo, sha)
+	}
+	out, err := git(ctx, d, "show", "--name-only", "--format=", sha)
+	if err != nil {
+		return nil, nil
+	}
+	return strings.Fields(out), nil
+}
+
+func (l *localRepos) ListTags(ctx context.Context, owner, repo string) ([]string, error) {
+	d, ok := l.dir(owner, repo)
+	if !ok {
+		return l.remote.ListTags(ctx, owner, repo)
+	}
+	out, err := git(ctx, d, "tag", "--sort=-creatordate")
+	if err != nil {
+		return nil, nil
+	}
+	return strings.Fields(out), nil
+}
+
+// git runs git in dir. Only dir's own .git counts, a fixture nested in
+// another checkout must not report the parent's history.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := cmd.Run()
+	return out.String(), err
+}
@@ -0,0 +1,147 @@
+package ghp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Note is a review observation labeled by the model.
+type Note struct {
+	Text     string `json:"text"`
+	Kind     string `json:"kind"`     // noteStrength, noteRisk or noteNeutral
+	Category string `json:"category"` // e.g. design, testing, security, error-handling
+	Severity string `json:"severity"` // low, medium or high
+	Count    int    `json:"count,omitempty"`
+}
+
+const (
+	noteStrength = "strength"
+	noteRisk     = "risk"
+	noteNeutral  = "neutral"
+)
+
+const notesPerRepo = 5
+
+var severityRank = map[string]int{"high": 3, "medium": 2, "low": 1}
+
+// UnmarshalJSON also accepts a plain string, which models still return
+// now and then, as a neutral note.
+func (n *Note) UnmarshalJSON(b []byte) error {
+	var text string
+	if err := json.Unmarshal(b, &text); err == nil {
+		*n = Note{Text: text, Kind: noteNeutral}
+		return nil
+	}
+
+	type plain Note
+	var p plain
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
+	}
+	*n = Note(p)
+	n.Kind = strings.ToLower(strings.TrimSpace(n.Kind))
+	n.Severity = strings.ToLower(strings.TrimSpace(n.Severity))
+	n.Category = strings.ToLower(strings.TrimSpace(n.Category))
+	if n.Kind != noteStrength && n.Kind != noteRisk {
+		n.Kind = noteNeutral
+	}
+	return nil
+}
+
+type consolidatedNotes struct {
+	Strengths []Note `json:"strengths"`
+	Risks     []Note `json:"risks"`
+}
+
+// consolidateNotes merges the labeled chunk notes of a repo into ranked
+// strengths and risks. The model merges duplicates and near-duplicates;
+// if that call fails, rankNotes does an exact-match pass locally.
+func (s *service) consolidateNotes(ctx context.Context, repo RepoTarget, notes []Note) (strengths, risks []Note) {
+	var b strings.Builder
+	for _, n := range notes {
+		if n.Kind == noteNeutral {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("[%s][%s][%s] %s\n", n.Kind, n.Category, n.Severity, n.Text))
+	}
+	if b.Len() == 0 {
+		return nil, nil
+	}
+
+	var out consolidatedNotes
+	err := s.llm.EvaluateJSON(ctx, EvalInput{
+		Prompt: s.notesPrompt,
+		Owner:  repo.Owner,
+		Repo:   repo.Name,
+		Branch: repo.DefaultBranch,
+		Chunks: []Chunk{{Path: "notes", Content: b.String(), Language: "text"}},
+	}, &out)
+	if err != nil || len(out.Strengths)+len(out.Risks) == 0 {
+		if err != nil {
+			fmt.Printf("warn: note consolidation failed for %s/%s: %v\n", repo.Owner, repo.Name, err)
+		}
+		return rankNotes(notes, noteStrength), rankNotes(notes, noteRisk)
+	}
+
+	for i := range out.Strengths {
+		out.Strengths[i].Kind = noteStrength
+	}
+	for i := range out.Risks {
+		out.Risks[i].Kind = noteRisk
+	}
+	return limitNotes(out.Strengths), limitNotes(out.Risks)
+}
+
+// rankNotes de-duplicates notes of one kind by text and ranks them by
+// severity, then by how often they came up.
+func rankNotes(notes []Note, kind string) []Note {
+	byText := map[string]*Note{}
+	var order []*Note
+	for _, n := range notes {
+		if n.Kind != kind {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(n.Text))
+		if prev, ok := byText[key]; ok {
+			prev.Count++
+			if severityRank[n.Severity] > severityRank[prev.Severity] {
+				prev.Severity = n.Severity
+			}
+			continue
+		}
+		n.Count = 1
+		byText[key] = &n
+		order = append(order, &n)
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if a, b := severityRank[order[i].Severity], severityRank[order[j].Severity]; a != b {
+			return a > b
+		}
+		return order[i].Count > order[j].Count
+	})
+
+	out := make([]Note, 0, len(order))
+	for _, n := range order {
+		out = append(out, *n)
+	}
+	return limitNotes(out)
+}
+
+func limitNotes(notes []Note) []Note {
+	if len(notes) > notesPerRepo {
+		return notes[:notesPerRepo]
+	}
+	return notes
+}
+
+func noteTexts(notes []Note) []string {
+	out := make([]string, len(notes))
+	for i, n := range notes {
+		out[i] = n.Text
+	}
+	return out
+}
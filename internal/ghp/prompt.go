@@ -1,10 +1,17 @@
 package ghp
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
 )
 
 func loadPrompt(fsys fs.FS, diskPath, embedPath string) (string, string, error) {
@@ -24,3 +31,275 @@ func loadPrompt(fsys fs.FS, diskPath, embedPath string) (string, string, error)
 
 	return "", "", fmt.Errorf("no prompt source available")
 }
+
+// Prompt is a prompt template, checked at load time against the data type
+// it is rendered with.
+type Prompt struct {
+	Name    string
+	Source  string // Where loadPrompt found it, e.g. embed:prompts/prompt.txt
+	Version string // From a leading {{/* version: N */}} comment, if any
+	Hash    string // Short sha256 of the prompt text
+	tmpl    *template.Template
+	fields  map[string]bool // Top-level fields the template references
+}
+
+// ChunkPromptData is the context of prompts evaluated per chunk: code,
+// test and docs reviews and note consolidation.
+type ChunkPromptData struct {
+	Owner     string
+	Repo      string
+	Branch    string
+	Path      string
+	Language  string
+	Symbol    string
+	StartLine int
+	EndLine   int
+	Content   string
+	// Review prompt only
+	Rubric        string
+	Scores        string
+	LanguageGuide string
+}
+
+// ArchPromptData is the context of the architecture prompts.
+type ArchPromptData struct {
+	Owner    string
+	Repo     string
+	Language string
+	Type     string // Repository type label, e.g. Web service
+	Reason   string // Signal the type was detected from
+	Tree     string
+	Health   string
+}
+
+// SummaryPromptData is the context of the profile summary prompts.
+type SummaryPromptData struct {
+	User        string
+	SummaryData string
+}
+
+// CommitPromptData is the context of the commit message prompt.
+type CommitPromptData struct {
+	Owner   string
+	Repo    string
+	Commits string
+}
+
+// promptLoader loads prompts from the embedded FS, or from dir when it has
+// a file at the same path, and keeps every prompt it loads.
+type promptLoader struct {
+	fsys   fs.FS
+	dir    string
+	loaded []*Prompt
+}
+
+// load loads a prompt for data's type. override, when set, takes precedence
+// over dir.
+func (l *promptLoader) load(embedPath, override string, data any) (*Prompt, error) {
+	diskPath := override
+	if diskPath == "" && l.dir != "" {
+		diskPath = filepath.Join(l.dir, strings.TrimPrefix(embedPath, "prompts/"))
+	}
+	p, err := loadPromptTemplate(l.fsys, diskPath, embedPath, data)
+	if err != nil {
+		return nil, err
+	}
+	l.loaded = append(l.loaded, p)
+	return p, nil
+}
+
+// loadPromptTemplate loads a prompt and parses it for data's type, failing
+// on syntax errors and on placeholders the type does not have.
+func loadPromptTemplate(fsys fs.FS, diskPath, embedPath string, data any) (*Prompt, error) {
+	text, source, err := loadPrompt(fsys, diskPath, embedPath)
+	if err != nil {
+		return nil, err
+	}
+	return newPrompt(embedPath, source, text, data)
+}
+
+var promptVersion = regexp.MustCompile(`^\{\{-?\s*/\*\s*version:\s*(\S+)\s*\*/\s*-?\}\}`)
+
+func newPrompt(name, source, text string, data any) (*Prompt, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	p := &Prompt{Name: name, Source: source, Hash: contentHash(text), tmpl: tmpl, fields: map[string]bool{}}
+	if m := promptVersion.FindStringSubmatch(text); m != nil {
+		p.Version = m[1]
+	}
+	if tmpl.Tree != nil {
+		root := reflect.TypeOf(data)
+		if err := p.check(tmpl.Tree.Root, root, root); err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+	}
+	return p, nil
+}
+
+// Render executes the template with data.
+func (p *Prompt) Render(data any) (string, error) {
+	var b strings.Builder
+	if err := p.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("prompt %s: %w", p.Name, err)
+	}
+	return b.String(), nil
+}
+
+// contentHash is the short sha256 prompts and rubrics are identified by.
+func contentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// String identifies the prompt text used in a run.
+func (p *Prompt) String() string {
+	version := "unversioned"
+	if p.Version != "" {
+		version = "v" + p.Version
+	}
+	return fmt.Sprintf("%s %s sha256:%s (%s)", p.Name, version, p.Hash, p.Source)
+}
+
+// Uses reports whether the template references a top-level field.
+func (p *Prompt) Uses(field string) bool {
+	return p.fields[field]
+}
+
+// check walks the template and resolves every field reference against the
+// type dot has at that point. Types it cannot follow, such as interfaces,
+// are not checked further.
+func (p *Prompt) check(node parse.Node, dot, root reflect.Type) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, c := range n.Nodes {
+			if err := p.check(c, dot, root); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		_, err := p.checkPipe(n.Pipe, dot, root)
+		return err
+	case *parse.IfNode:
+		return p.checkBranch(&n.BranchNode, dot, dot, root)
+	case *parse.WithNode:
+		t, err := p.checkPipe(n.Pipe, dot, root)
+		if err != nil {
+			return err
+		}
+		return p.checkBranch(&n.BranchNode, t, dot, root)
+	case *parse.RangeNode:
+		t, err := p.checkPipe(n.Pipe, dot, root)
+		if err != nil {
+			return err
+		}
+		if t != nil {
+			switch t.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+				t = t.Elem()
+			default:
+				t = nil
+			}
+		}
+		return p.checkBranch(&n.BranchNode, t, dot, root)
+	case *parse.TemplateNode:
+		return fmt.Errorf("line %d: template calls are not supported in prompts", n.Line)
+	}
+	return nil
+}
+
+func (p *Prompt) checkBranch(n *parse.BranchNode, inner, dot, root reflect.Type) error {
+	if _, err := p.checkPipe(n.Pipe, dot, root); err != nil {
+		return err
+	}
+	if err := p.check(n.List, inner, root); err != nil {
+		return err
+	}
+	return p.check(n.ElseList, dot, root)
+}
+
+// checkPipe checks the fields a pipeline references and returns the type
+// it yields when that is a plain field, or nil when unknown.
+func (p *Prompt) checkPipe(pipe *parse.PipeNode, dot, root reflect.Type) (reflect.Type, error) {
+	if pipe == nil {
+		return nil, nil
+	}
+	var last reflect.Type
+	for _, cmd := range pipe.Cmds {
+		last = nil
+		for _, arg := range cmd.Args {
+			var t reflect.Type
+			var err error
+			switch a := arg.(type) {
+			case *parse.FieldNode:
+				if dot == root && len(a.Ident) > 0 {
+					p.fields[a.Ident[0]] = true
+				}
+				t, err = fieldType(dot, a.Ident)
+			case *parse.VariableNode:
+				if a.Ident[0] == "$" && len(a.Ident) > 1 {
+					p.fields[a.Ident[1]] = true
+					t, err = fieldType(root, a.Ident[1:])
+				}
+			case *parse.PipeNode:
+				t, err = p.checkPipe(a, dot, root)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", pipe.Line, err)
+			}
+			if len(cmd.Args) == 1 {
+				last = t
+			}
+		}
+	}
+	if len(pipe.Cmds) != 1 {
+		return nil, nil
+	}
+	return last, nil
+}
+
+func fieldType(t reflect.Type, idents []string) (reflect.Type, error) {
+	for _, id := range idents {
+		if t == nil {
+			return nil, nil
+		}
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			if m, ok := reflect.PointerTo(t).MethodByName(id); ok {
+				if m.Type.NumOut() == 0 {
+					return nil, nil
+				}
+				t = m.Type.Out(0)
+				continue
+			}
+			f, ok := t.FieldByName(id)
+			if !ok || !f.IsExported() {
+				return nil, fmt.Errorf("unknown placeholder {{.%s}}, %s has %s", id, t.Name(), strings.Join(exportedFields(t), ", "))
+			}
+			t = f.Type
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, nil
+		}
+	}
+	return t, nil
+}
+
+func exportedFields(t reflect.Type) []string {
+	var out []string
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.IsExported() {
+			out = append(out, f.Name)
+		}
+	}
+	return out
+}
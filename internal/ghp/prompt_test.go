@@ -0,0 +1,80 @@
+package ghp
+
+import (
+	"strings"
+	"testing"
+)
+
+type testPromptData struct {
+	Name  string
+	Files []struct {
+		Path  string
+		Lines int
+	}
+	Repo struct {
+		Owner string
+	}
+	Tags   map[string]string
+	Health *RepoHealth
+}
+
+func TestNewPrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr string
+	}{
+		{"plain text", "no placeholders", ""},
+		{"field", "{{.Name}}", ""},
+		{"typo", "{{.Nmae}}", "unknown placeholder {{.Nmae}}, testPromptData has Name, Files, Repo, Tags, Health"},
+		{"if else", "{{if .Name}}{{.Name}}{{else}}{{.Tags}}{{end}}", ""},
+		{"typo in if", "{{if .Nmae}}x{{end}}", "unknown placeholder {{.Nmae}}"},
+		{"typo in else", "{{if .Name}}x{{else}}{{.Nmae}}{{end}}", "unknown placeholder {{.Nmae}}"},
+		{"range element", "{{range .Files}}{{.Path}}:{{.Lines}}{{end}}", ""},
+		{"typo in range", "{{range .Files}}{{.Paht}}{{end}}", "unknown placeholder {{.Paht}}"},
+		{"root field in range", "{{range .Files}}{{.Name}}{{end}}", "unknown placeholder {{.Name}}"},
+		{"root variable in range", "{{range .Files}}{{$.Name}}{{end}}", ""},
+		{"root variable typo in range", "{{range .Files}}{{$.Nmae}}{{end}}", "unknown placeholder {{.Nmae}}"},
+		{"range else is outer dot", "{{range .Files}}x{{else}}{{.Name}}{{end}}", ""},
+		{"with", "{{with .Repo}}{{.Owner}}{{end}}", ""},
+		{"typo in with", "{{with .Repo}}{{.Ownr}}{{end}}", "unknown placeholder {{.Ownr}}"},
+		{"nested with in range", "{{range .Files}}{{with .Path}}{{.}}{{end}}{{end}}", ""},
+		{"nested path", "{{.Repo.Ownr}}", "unknown placeholder {{.Ownr}}"},
+		{"map key", "{{.Tags.anything}}", ""},
+		{"pointer field", "{{.Health.Nope}}", "unknown placeholder {{.Nope}}"},
+		{"function argument", "{{printf \"%s\" .Nmae}}", "unknown placeholder {{.Nmae}}"},
+		{"parenthesized pipe", "{{len (.Nmae)}}", "unknown placeholder {{.Nmae}}"},
+		{"line number", "a\nb\n{{.Nmae}}", "line 3"},
+		{"template call", "{{define \"x\"}}y{{end}}{{template \"x\"}}", "template calls are not supported in prompts"},
+		{"syntax error", "{{.Name", "unclosed action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newPrompt("test.txt", "embedded", tt.text, testPromptData{})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPromptVersionAndUses(t *testing.T) {
+	p, err := newPrompt("test.txt", "embedded", "{{/* version: 3 */ -}}\n{{.Name}}{{range .Files}}{{.Path}}{{end}}", testPromptData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Version != "3" {
+		t.Errorf("Version = %q, want 3", p.Version)
+	}
+	for field, want := range map[string]bool{"Name": true, "Files": true, "Path": false, "Repo": false} {
+		if got := p.Uses(field); got != want {
+			t.Errorf("Uses(%q) = %v, want %v", field, got, want)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package ghp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	secondaryLimitBackoff = time.Minute
+	etagCacheTTL          = 24 * 30 * time.Hour
+)
+
+type rateState struct {
+	Remaining int
+	Reset     time.Time
+}
+
+// rateLimitTransport keeps track of the GitHub quota reported in the
+// X-RateLimit-* headers. It waits for the reset when the quota is exhausted,
+// retries secondary-limit responses and turns cached ETags into conditional
+// requests, so a 304 is served from disk without spending quota.
+type rateLimitTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	mu         sync.Mutex
+	limits     map[string]rateState
+}
+
+type etagEntry struct {
+	ETag   string
+	Header http.Header
+	Body   []byte
+}
+
+func newRateLimitTransport(base http.RoundTripper, maxRetries int) *rateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitTransport{
+		base:       base,
+		maxRetries: maxRetries,
+		limits:     make(map[string]rateState),
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	resource := rateResource(req)
+
+	if err := t.waitForQuota(ctx, resource); err != nil {
+		return nil, err
+	}
+
+	var cached *etagEntry
+	var cachePath string
+	if req.Method == http.MethodGet && !isPrivateRepo(ctx) {
+		cachePath, cached = t.loadETag(req)
+		if cached != nil {
+			req = req.Clone(ctx)
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		t.track(resource, resp)
+
+		wait, limited := t.limitedFor(resource, resp)
+		if limited && attempt < t.maxRetries {
+			resp.Body.Close()
+			fmt.Printf("warn: GitHub rate limit hit on %s, retrying in %s (attempt %d/%d)\n", resource, wait.Round(time.Second), attempt+1, t.maxRetries)
+			if err := sleepCtx(ctx, wait); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if resp.StatusCode == http.StatusNotModified && cached != nil {
+			resp.Body.Close()
+			return cachedResponse(req, cached, resp.Header), nil
+		}
+
+		if resp.StatusCode == http.StatusOK && cachePath != "" && resp.Header.Get("ETag") != "" {
+			b, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(b))
+			entry := etagEntry{ETag: resp.Header.Get("ETag"), Header: resp.Header, Body: b}
+			if err := writeCache(cachePath, entry); err != nil {
+				fmt.Printf("warn: cache write error: %v\n", err)
+			}
+		}
+
+		// NOTE: go-github refuses to send requests while its last known quota
+		// is zero, so we wait for the reset here, before it sees the response.
+		if err := t.waitForQuota(ctx, resource); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+
+		return resp, nil
+	}
+}
+
+func (t *rateLimitTransport) waitForQuota(ctx context.Context, resource string) error {
+	t.mu.Lock()
+	st, ok := t.limits[resource]
+	t.mu.Unlock()
+	if !ok || st.Remaining > 0 {
+		return nil
+	}
+
+	wait := time.Until(st.Reset)
+	if wait <= 0 {
+		return nil
+	}
+
+	fmt.Printf("warn: GitHub %s quota exhausted, sleeping until %s\n", resource, st.Reset.Format(time.Kitchen))
+	return sleepCtx(ctx, wait+time.Second)
+}
+
+func (t *rateLimitTransport) track(resource string, resp *http.Response) {
+	rem := resp.Header.Get("X-RateLimit-Remaining")
+	if rem == "" {
+		return
+	}
+
+	remaining, err := strconv.Atoi(rem)
+	if err != nil {
+		return
+	}
+
+	var reset time.Time
+	if secs, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		reset = time.Unix(secs, 0)
+	}
+
+	t.mu.Lock()
+	t.limits[resource] = rateState{Remaining: remaining, Reset: reset}
+	t.mu.Unlock()
+}
+
+// limitedFor reports whether the response is a primary or secondary rate
+// limit rejection and, if so, how long to wait before trying again.
+func (t *rateLimitTransport) limitedFor(resource string, resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if ra := resp.Header.Get("Retry-After"); ra != "" {
+		if secs, err := strconv.Atoi(ra); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+	}
+
+	t.mu.Lock()
+	st, ok := t.limits[resource]
+	t.mu.Unlock()
+	if ok && st.Remaining == 0 && time.Until(st.Reset) > 0 {
+		return time.Until(st.Reset) + time.Second, true
+	}
+
+	// NOTE: Secondary limits and abuse detection are only recognizable by
+	// the message, so we peek at the body and put it back.
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return 0, false
+	}
+
+	msg := strings.ToLower(string(b))
+	if strings.Contains(msg, "secondary rate limit") || strings.Contains(msg, "abuse") {
+		return secondaryLimitBackoff, true
+	}
+
+	return 0, false
+}
+
+func (t *rateLimitTransport) loadETag(req *http.Request) (string, *etagEntry) {
+	key := sha256.Sum256([]byte(req.Header.Get("Accept") + " " + req.URL.String()))
+	path, err := getCachePath("etag", hex.EncodeToString(key[:])+".json")
+	if err != nil {
+		return "", nil
+	}
+
+	var entry etagEntry
+	hit, err := readCache(path, &entry, etagCacheTTL)
+	if err != nil {
+		fmt.Printf("warn: cache read error: %v\n", err)
+	}
+	if !hit || entry.ETag == "" {
+		return path, nil
+	}
+
+	return path, &entry
+}
+
+// rateHeaders are taken from the live 304, the stored ones may be days old
+// and go-github reads its quota from them.
+var rateHeaders = []string{
+	"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Used", "X-RateLimit-Reset", "X-RateLimit-Resource",
+}
+
+// cachedResponse replays a stored 200 for a 304, with the rate limit
+// headers of the live response.
+func cachedResponse(req *http.Request, e *etagEntry, live http.Header) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	for _, h := range rateHeaders {
+		header.Del(h)
+		if v := live.Values(h); len(v) > 0 {
+			header[http.CanonicalHeaderKey(h)] = v
+		}
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+func rateResource(req *http.Request) string {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/graphql"):
+		return "graphql"
+	case strings.HasPrefix(req.URL.Path, "/search/"):
+		return "search"
+	default:
+		return "core"
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
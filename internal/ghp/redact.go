@@ -0,0 +1,135 @@
+package ghp
+
+import (
+	"cmp"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Redaction records a secret or piece of PII removed from a chunk before it
+// was sent to the LLM. The value itself is never kept.
+type Redaction struct {
+	Path string
+	Line int
+	Kind string
+}
+
+type redactRule struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// redactRules run in order; specific providers come before the generic
+// high-entropy rule so findings get the most precise kind.
+var redactRules = []redactRule{
+	{"PRIVATE_KEY", regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+	{"AWS_ACCESS_KEY", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GITHUB_TOKEN", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"OPENAI_KEY", regexp.MustCompile(`\bsk-(proj-)?[A-Za-z0-9_-]{20,}`)},
+	{"STRIPE_KEY", regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{16,}\b`)},
+	{"SLACK_TOKEN", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"GOOGLE_API_KEY", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{"CREDENTIAL_URL", regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://[^\s:/@"']+:[^\s:/@"']+@`)},
+	{"EMAIL", regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+}
+
+var entropyCandidateRe = regexp.MustCompile(`[A-Za-z0-9+/_=-]{24,}`)
+
+const minSecretEntropy = 4.2
+
+func redactPlaceholder(kind string) string {
+	return "[REDACTED:" + kind + "]"
+}
+
+// redactChunks replaces secrets and PII with typed placeholders such as
+// [REDACTED:GITHUB_TOKEN]. Line breaks inside a match are kept, so line
+// numbers in the chunk stay valid for citations.
+func redactChunks(chunks []FileChunk) ([]FileChunk, []Redaction) {
+	out := make([]FileChunk, len(chunks))
+	var found []Redaction
+	for i, c := range chunks {
+		for _, rule := range redactRules {
+			c.Content = redactMatches(c, rule.re, rule.kind, nil, &found)
+		}
+		c.Content = redactMatches(c, entropyCandidateRe, "HIGH_ENTROPY_STRING", looksLikeSecret, &found)
+		out[i] = c
+	}
+
+	sortRedactions(found)
+	return out, found
+}
+
+// sortRedactions orders findings by path and line for the report.
+func sortRedactions(found []Redaction) {
+	slices.SortStableFunc(found, func(a, b Redaction) int {
+		return cmp.Or(strings.Compare(a.Path, b.Path), a.Line-b.Line)
+	})
+}
+
+func redactMatches(c FileChunk, re *regexp.Regexp, kind string, accept func(string) bool, found *[]Redaction) string {
+	locs := re.FindAllStringIndex(c.Content, -1)
+	if len(locs) == 0 {
+		return c.Content
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range locs {
+		match := c.Content[loc[0]:loc[1]]
+		if accept != nil && !accept(match) {
+			continue
+		}
+		b.WriteString(c.Content[last:loc[0]])
+		b.WriteString(redactPlaceholder(kind))
+		b.WriteString(strings.Repeat("\n", strings.Count(match, "\n")))
+		last = loc[1]
+
+		*found = append(*found, Redaction{
+			Path: c.Path,
+			Line: c.StartLine + strings.Count(c.Content[:loc[0]], "\n"),
+			Kind: kind,
+		})
+	}
+	b.WriteString(c.Content[last:])
+	return b.String()
+}
+
+// looksLikeSecret accepts long random-looking tokens: mixed letter case and
+// digits with high Shannon entropy. Identifiers, paths and hex digests
+// rarely pass both checks.
+func looksLikeSecret(s string) bool {
+	var upper, lower, digit bool
+	for _, r := range s {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+	return shannonEntropy(s) >= minSecretEntropy
+}
+
+func shannonEntropy(s string) float64 {
+	freq := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		freq[r]++
+		n++
+	}
+
+	var h float64
+	for _, f := range freq {
+		p := float64(f) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -3,8 +3,10 @@ package ghp
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v61/github"
@@ -14,9 +16,13 @@ import (
 
 type ghRepo interface {
 	DiscoverUserRepos(ctx context.Context, handle string, opt discoverOptions) ([]RepoTarget, error)
+	GetDefaultBranch(ctx context.Context, owner, repo string) (string, error)
 	GetLatestCommitSHA(ctx context.Context, owner, repo, ref string) (string, error)
-	ListTree(ctx context.Context, owner, repo, ref, sha string) ([]string, error)
+	ListTree(ctx context.Context, owner, repo, ref, sha string) ([]TreeEntry, error)
 	ReadFile(ctx context.Context, owner, repo, ref, path, sha string) ([]byte, error)
+	ListCommits(ctx context.Context, owner, repo, ref, sha string, limit int) ([]CommitInfo, error)
+	ListCommitFiles(ctx context.Context, owner, repo, sha string) ([]string, error)
+	ListTags(ctx context.Context, owner, repo string) ([]string, error)
 }
 
 type discoverOptions struct {
@@ -24,22 +30,91 @@ type discoverOptions struct {
 	IncludePinned    bool
 	IncludeNonPinned bool
 	ExcludeForks     bool
+	IncludePrivate   bool
+	PrivateAllowlist []string
 }
 
 type ghRepoImpl struct {
 	restClient    *github.Client
 	graphqlClient *graphql.Client
+	cacheKey      []byte
+	mu            sync.RWMutex
+	private       map[string]bool
 }
 
-func newGitHubRepo(token string) (ghRepo, error) {
-	src := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	httpClient := oauth2.NewClient(context.Background(), src)
+func newGitHubRepo(src oauth2.TokenSource, maxRetries int, cacheKey string) (ghRepo, error) {
+	httpClient := &http.Client{
+		Transport: newRateLimitTransport(&oauth2.Transport{Source: src}, maxRetries),
+	}
 	return &ghRepoImpl{
 		restClient:    github.NewClient(httpClient),
 		graphqlClient: graphql.NewClient("https://api.github.com/graphql", httpClient),
+		cacheKey:      deriveCacheKey(cacheKey),
+		private:       make(map[string]bool),
 	}, nil
 }
 
+type ctxKey int
+
+const privateRepoKey ctxKey = iota
+
+// withPrivateRepo marks requests made on behalf of a private repository, so
+// the transport never stores their responses in the plain ETag cache.
+func withPrivateRepo(ctx context.Context) context.Context {
+	return context.WithValue(ctx, privateRepoKey, true)
+}
+
+func isPrivateRepo(ctx context.Context) bool {
+	v, _ := ctx.Value(privateRepoKey).(bool)
+	return v
+}
+
+func (g *ghRepoImpl) markPrivate(targets []RepoTarget) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, t := range targets {
+		if t.Private {
+			g.private[strings.ToLower(t.Owner+"/"+t.Name)] = true
+		}
+	}
+}
+
+func (g *ghRepoImpl) isPrivate(owner, repo string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.private[strings.ToLower(owner+"/"+repo)]
+}
+
+// repoContext returns the context to use for requests against owner/repo.
+func (g *ghRepoImpl) repoContext(ctx context.Context, owner, repo string) context.Context {
+	if g.isPrivate(owner, repo) {
+		return withPrivateRepo(ctx)
+	}
+	return ctx
+}
+
+// readRepoCache reads a per-repo cache entry. Private repositories are only
+// cached sealed with the cache key, and not at all when there is no key.
+func (g *ghRepoImpl) readRepoCache(owner, repo, path string, target any, ttl time.Duration) (bool, error) {
+	if !g.isPrivate(owner, repo) {
+		return readCache(path, target, ttl)
+	}
+	if g.cacheKey == nil {
+		return false, nil
+	}
+	return readSealedCache(path+".sealed", target, ttl, g.cacheKey)
+}
+
+func (g *ghRepoImpl) writeRepoCache(owner, repo, path string, data any) error {
+	if !g.isPrivate(owner, repo) {
+		return writeCache(path, data)
+	}
+	if g.cacheKey == nil {
+		return nil
+	}
+	return writeSealedCache(path+".sealed", data, g.cacheKey)
+}
+
 type userRepoQuery struct {
 	User struct {
 		PinnedItems struct {
@@ -53,6 +128,16 @@ type userRepoQuery struct {
 	} `graphql:"user(login: $login)"`
 }
 
+// viewerRepoQuery lists the private repositories the token itself can see.
+// It only runs when include_private is set.
+type viewerRepoQuery struct {
+	Viewer struct {
+		Repositories struct {
+			Nodes []repoGraphQL
+		} `graphql:"repositories(first: 100, privacy: PRIVATE, ownerAffiliations: [OWNER, COLLABORATOR, ORGANIZATION_MEMBER], orderBy: {field: PUSHED_AT, direction: DESC})"`
+	}
+}
+
 type repoGraphQL struct {
 	NameWithOwner    string
 	DefaultBranchRef struct {
@@ -60,6 +145,7 @@ type repoGraphQL struct {
 	}
 	StargazerCount int
 	IsFork         bool
+	IsPrivate      bool
 	Owner          struct {
 		Login string
 	}
@@ -71,17 +157,39 @@ type repoGraphQL struct {
 }
 
 func (g *ghRepoImpl) DiscoverUserRepos(ctx context.Context, handle string, opt discoverOptions) ([]RepoTarget, error) {
-	cachePath, err := getCachePath(fmt.Sprintf("repos-%s.json", handle))
+	cacheName := fmt.Sprintf("repos-%s.json", handle)
+	if opt.IncludePrivate {
+		cacheName = fmt.Sprintf("repos-%s-private.json", handle)
+	}
+	cachePath, err := getCachePath(cacheName)
 	if err != nil {
 		return nil, err
 	}
 
+	allowed := make(map[string]bool, len(opt.PrivateAllowlist))
+	for _, name := range opt.PrivateAllowlist {
+		allowed[strings.ToLower(name)] = true
+	}
+	if opt.IncludePrivate && len(allowed) == 0 {
+		fmt.Println("warn: include_private is set but private_allowlist is empty; no private repositories will be profiled")
+	}
+
+	// NOTE: Private repositories need both the opt-in and an allowlist entry.
+	consented := func(r repoGraphQL) bool {
+		return !r.IsPrivate || (opt.IncludePrivate && allowed[strings.ToLower(r.NameWithOwner)])
+	}
+
 	var cachedRepos []RepoTarget
 	hit, err := readCache(cachePath, &cachedRepos, 1*time.Hour)
 	if err != nil {
 		fmt.Printf("warn: cache read error: %v\n", err)
 	}
 	if hit {
+		// The allowlist may have changed since the list was cached.
+		cachedRepos = slices.DeleteFunc(cachedRepos, func(r RepoTarget) bool {
+			return r.Private && !(opt.IncludePrivate && allowed[strings.ToLower(r.Owner+"/"+r.Name)])
+		})
+		g.markPrivate(cachedRepos)
 		return cachedRepos, nil
 	}
 
@@ -105,6 +213,9 @@ func (g *ghRepoImpl) DiscoverUserRepos(ctx context.Context, handle string, opt d
 			if opt.ExcludeForks && r.IsFork {
 				continue
 			}
+			if !consented(r) {
+				continue
+			}
 			repoMap[r.NameWithOwner] = repoGraphQLToTarget(r, true)
 			pinnedOrder = append(pinnedOrder, r.NameWithOwner)
 		}
@@ -121,6 +232,25 @@ func (g *ghRepoImpl) DiscoverUserRepos(ctx context.Context, handle string, opt d
 			if opt.ExcludeForks && r.IsFork {
 				continue
 			}
+			if !consented(r) {
+				continue
+			}
+			repoMap[r.NameWithOwner] = repoGraphQLToTarget(r, false)
+		}
+	}
+
+	if opt.IncludePrivate && len(allowed) > 0 {
+		var viewer viewerRepoQuery
+		if err := g.graphqlClient.Query(ctx, &viewer, nil); err != nil {
+			return nil, fmt.Errorf("graphql viewer query: %w", err)
+		}
+		for _, r := range viewer.Viewer.Repositories.Nodes {
+			if _, exists := repoMap[r.NameWithOwner]; exists {
+				continue
+			}
+			if !consented(r) {
+				continue
+			}
 			repoMap[r.NameWithOwner] = repoGraphQLToTarget(r, false)
 		}
 	}
@@ -149,6 +279,7 @@ func (g *ghRepoImpl) DiscoverUserRepos(ctx context.Context, handle string, opt d
 		fmt.Printf("warn: cache write error: %v\n", err)
 	}
 
+	g.markPrivate(targets)
 	return targets, nil
 }
 
@@ -160,10 +291,21 @@ func repoGraphQLToTarget(r repoGraphQL, pinned bool) RepoTarget {
 		Stars:         r.StargazerCount,
 		Pinned:        pinned,
 		Language:      r.PrimaryLanguage.Name,
+		Private:       r.IsPrivate,
+	}
+}
+
+func (g *ghRepoImpl) GetDefaultBranch(ctx context.Context, owner, repo string) (string, error) {
+	ctx = g.repoContext(ctx, owner, repo)
+	r, _, err := g.restClient.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return "", err
 	}
+	return r.GetDefaultBranch(), nil
 }
 
 func (g *ghRepoImpl) GetLatestCommitSHA(ctx context.Context, owner, repo, ref string) (string, error) {
+	ctx = g.repoContext(ctx, owner, repo)
 	r, _, err := g.restClient.Git.GetRef(ctx, owner, repo, "heads/"+ref)
 	if err != nil {
 		return "", err
@@ -171,13 +313,15 @@ func (g *ghRepoImpl) GetLatestCommitSHA(ctx context.Context, owner, repo, ref st
 	return r.GetObject().GetSHA(), nil
 }
 
-func (g *ghRepoImpl) ListTree(ctx context.Context, owner, repo, ref, sha string) ([]string, error) {
-	cachePath, err := getCachePath(owner, repo, fmt.Sprintf("%s-tree.json", sha))
+func (g *ghRepoImpl) ListTree(ctx context.Context, owner, repo, ref, sha string) ([]TreeEntry, error) {
+	cachePath, err := getCachePath(owner, repo, fmt.Sprintf("%s-entries.json", sha))
 	if err != nil {
 		return nil, err
 	}
-	var cachedTree []string
-	hit, err := readCache(cachePath, &cachedTree, 24*30*time.Hour) // Long TTL for commit-based cache
+	ctx = g.repoContext(ctx, owner, repo)
+
+	var cachedTree []TreeEntry
+	hit, err := g.readRepoCache(owner, repo, cachePath, &cachedTree, 24*30*time.Hour) // Long TTL for commit-based cache
 	if err != nil {
 		fmt.Printf("warn: cache read error: %v\n", err)
 	}
@@ -190,19 +334,52 @@ func (g *ghRepoImpl) ListTree(ctx context.Context, owner, repo, ref, sha string)
 		return nil, err
 	}
 
-	paths := make([]string, 0, len(tree.Entries))
+	entries := make([]TreeEntry, 0, len(tree.Entries))
 	for _, te := range tree.Entries {
 		if te == nil || te.GetType() != "blob" {
 			continue
 		}
-		paths = append(paths, te.GetPath())
+		entries = append(entries, TreeEntry{Path: te.GetPath(), Size: te.GetSize()})
+	}
+
+	if err := g.writeRepoCache(owner, repo, cachePath, entries); err != nil {
+		fmt.Printf("warn: cache write error: %v\n", err)
+	}
+
+	return entries, nil
+}
+
+func (g *ghRepoImpl) ListCommitFiles(ctx context.Context, owner, repo, sha string) ([]string, error) {
+	cachePath, err := getCachePath(owner, repo, fmt.Sprintf("%s-files.json", sha))
+	if err != nil {
+		return nil, err
+	}
+	ctx = g.repoContext(ctx, owner, repo)
+
+	var cachedFiles []string
+	hit, err := g.readRepoCache(owner, repo, cachePath, &cachedFiles, 24*30*time.Hour)
+	if err != nil {
+		fmt.Printf("warn: cache read error: %v\n", err)
+	}
+	if hit {
+		return cachedFiles, nil
+	}
+
+	commit, _, err := g.restClient.Repositories.GetCommit(ctx, owner, repo, sha, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(commit.Files))
+	for _, f := range commit.Files {
+		files = append(files, f.GetFilename())
 	}
 
-	if err := writeCache(cachePath, paths); err != nil {
+	if err := g.writeRepoCache(owner, repo, cachePath, files); err != nil {
 		fmt.Printf("warn: cache write error: %v\n", err)
 	}
 
-	return paths, nil
+	return files, nil
 }
 
 func (g *ghRepoImpl) ReadFile(ctx context.Context, owner, repo, ref, path, sha string) ([]byte, error) {
@@ -212,8 +389,10 @@ func (g *ghRepoImpl) ReadFile(ctx context.Context, owner, repo, ref, path, sha s
 		return nil, err
 	}
 
+	ctx = g.repoContext(ctx, owner, repo)
+
 	var cachedContent []byte
-	hit, err := readCache(cachePath, &cachedContent, 24*30*time.Hour)
+	hit, err := g.readRepoCache(owner, repo, cachePath, &cachedContent, 24*30*time.Hour)
 	if err != nil {
 		fmt.Printf("warn: cache read error: %v\n", err)
 	}
@@ -232,9 +411,146 @@ func (g *ghRepoImpl) ReadFile(ctx context.Context, owner, repo, ref, path, sha s
 	}
 	contentBytes := []byte(c)
 
-	if err := writeCache(cachePath, contentBytes); err != nil {
+	if err := g.writeRepoCache(owner, repo, cachePath, contentBytes); err != nil {
 		fmt.Printf("warn: cache write error: %v\n", err)
 	}
 
 	return contentBytes, nil
 }
+
+type commitHistoryQuery struct {
+	Repository struct {
+		Object struct {
+			Commit struct {
+				History struct {
+					PageInfo struct {
+						HasNextPage bool
+						EndCursor   graphql.String
+					}
+					Nodes []struct {
+						Oid           string
+						Message       string
+						CommittedDate time.Time
+						Additions     int
+						Deletions     int
+						Author        struct {
+							Name string
+						}
+					}
+				} `graphql:"history(first: $first, after: $after)"`
+			} `graphql:"... on Commit"`
+		} `graphql:"object(expression: $expr)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+func (g *ghRepoImpl) ListCommits(ctx context.Context, owner, repo, ref, sha string, limit int) ([]CommitInfo, error) {
+	expr := sha
+	if expr == "" {
+		expr = ref
+	}
+
+	cachePath, err := getCachePath(owner, repo, fmt.Sprintf("%s-history-%d.json", strings.ReplaceAll(expr, "/", "_"), limit))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = g.repoContext(ctx, owner, repo)
+
+	var cachedCommits []CommitInfo
+	ttl := 24 * 30 * time.Hour
+	if sha == "" {
+		ttl = 1 * time.Hour // A branch name moves, a SHA does not
+	}
+	hit, err := g.readRepoCache(owner, repo, cachePath, &cachedCommits, ttl)
+	if err != nil {
+		fmt.Printf("warn: cache read error: %v\n", err)
+	}
+	if hit {
+		return cachedCommits, nil
+	}
+
+	limit = max(limit, 1)
+	var commits []CommitInfo
+	var after *graphql.String
+	for len(commits) < limit {
+		// NOTE: GraphQL caps connections at 100 nodes per page.
+		var query commitHistoryQuery
+		variables := map[string]interface{}{
+			"owner": graphql.String(owner),
+			"name":  graphql.String(repo),
+			"expr":  graphql.String(expr),
+			"first": graphql.Int(min(limit-len(commits), 100)),
+			"after": after,
+		}
+		if err := g.graphqlClient.Query(ctx, &query, variables); err != nil {
+			return nil, fmt.Errorf("graphql history query: %w", err)
+		}
+
+		history := query.Repository.Object.Commit.History
+		for _, n := range history.Nodes {
+			commits = append(commits, CommitInfo{
+				SHA:       n.Oid,
+				Message:   n.Message,
+				Author:    n.Author.Name,
+				Date:      n.CommittedDate,
+				Additions: n.Additions,
+				Deletions: n.Deletions,
+			})
+		}
+		if !history.PageInfo.HasNextPage || len(history.Nodes) == 0 {
+			break
+		}
+		cursor := history.PageInfo.EndCursor
+		after = &cursor
+	}
+
+	if err := g.writeRepoCache(owner, repo, cachePath, commits); err != nil {
+		fmt.Printf("warn: cache write error: %v\n", err)
+	}
+
+	return commits, nil
+}
+
+// maxTagPages bounds ListTags to the first thousand tags.
+const maxTagPages = 10
+
+// ListTags lists tag names in no particular order; the API does not sort
+// them by version or date.
+func (g *ghRepoImpl) ListTags(ctx context.Context, owner, repo string) ([]string, error) {
+	cachePath, err := getCachePath(owner, repo, "tags.json")
+	if err != nil {
+		return nil, err
+	}
+	ctx = g.repoContext(ctx, owner, repo)
+
+	var cachedTags []string
+	hit, err := g.readRepoCache(owner, repo, cachePath, &cachedTags, 1*time.Hour) // Tags move, keep it short
+	if err != nil {
+		fmt.Printf("warn: cache read error: %v\n", err)
+	}
+	if hit {
+		return cachedTags, nil
+	}
+
+	var names []string
+	opt := &github.ListOptions{PerPage: 100}
+	for page := 0; page < maxTagPages; page++ {
+		tags, resp, err := g.restClient.Repositories.ListTags(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tags {
+			names = append(names, t.GetName())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	if err := g.writeRepoCache(owner, repo, cachePath, names); err != nil {
+		fmt.Printf("warn: cache write error: %v\n", err)
+	}
+
+	return names, nil
+}
@@ -3,11 +3,12 @@ package ghp
 import (
 	"fmt"
 	"html"
+	"math"
 	"sort"
 	"strings"
 )
 
-func renderHTML(user string, results []RepoResult, headlineHTML, summaryHTML string) string {
+func renderHTML(user string, results []RepoResult, headlineHTML, summaryHTML string, meta ReportMeta) string {
 	// Language stats
 	langCounts := make(map[string]int)
 	for _, r := range results {
@@ -43,25 +44,26 @@ func renderHTML(user string, results []RepoResult, headlineHTML, summaryHTML str
   </section>`, langTags.String())
 	}
 
-	var codeRows, archRows strings.Builder
+	// Private code watermark
+	var watermark, titlePrefix string
 	for _, r := range results {
-		// Code Analysis Row
-		ss := "—"
-		if len(r.Strengths) > 0 {
-			ss = "<ul>"
-			for _, s := range r.Strengths {
-				ss += "<li>" + html.EscapeString(s) + "</li>"
-			}
-			ss += "</ul>"
+		if r.Repo.Private {
+			titlePrefix = "CONFIDENTIAL – "
+			watermark = `<div class="mb-6 p-3 bg-red-50 border-l-4 border-red-500 text-red-800 text-sm font-semibold uppercase tracking-wide">Confidential – this report includes private repositories. Do not share outside the evaluation loop.</div>`
+			break
 		}
-		rs := "—"
-		if len(r.Risks) > 0 {
-			rs = "<ul>"
-			for _, r := range r.Risks {
-				rs += "<li>" + html.EscapeString(r) + "</li>"
-			}
-			rs += "</ul>"
+	}
+
+	var codeRows, archRows, historyRows, docsRows, healthRows, staticRows, depsRows, excludedList, redactedList strings.Builder
+	for _, r := range results {
+		privateTag := ""
+		if r.Repo.Private {
+			privateTag = ` <span class="inline-block bg-red-100 text-red-800 text-xs font-semibold px-2 py-0.5 rounded-full">private</span>`
 		}
+
+		// Code Analysis Row
+		ss := noteList(r.Strengths)
+		rs := noteList(r.Risks)
 		var sm []string
 		for _, s := range r.Samples {
 			sm = append(sm, fmt.Sprintf(`<a class="underline" href="%s" target="_blank" rel="noreferrer">sample</a>`, s.URL))
@@ -72,13 +74,14 @@ func renderHTML(user string, results []RepoResult, headlineHTML, summaryHTML str
 		}
 		codeRows.WriteString(fmt.Sprintf(
 			`<tr class="border-b">
-<td class="py-2 px-3 font-medium align-top">%s/%s</td>
-<td class="py-2 px-3 text-right align-top">%d</td>
+<td class="py-2 px-3 font-medium align-top">%s/%s%s</td>
+<td class="py-2 px-3 text-right align-top">%s</td>
 <td class="py-2 px-3">%s</td>
 <td class="py-2 px-3">%s</td>
 <td class="py-2 px-3 align-top">%s</td>
+<td class="py-2 px-3 align-top">%s</td>
 </tr>`,
-			html.EscapeString(r.Repo.Owner), html.EscapeString(r.Repo.Name), r.Score, ss, rs, samples,
+			html.EscapeString(r.Repo.Owner), html.EscapeString(r.Repo.Name), privateTag, scoreCell(r.Score, r.Confidence, r.Calibrated), ss, rs, testingCell(r.Testing), samples,
 		))
 
 		// Architecture Analysis Row
@@ -98,14 +101,254 @@ func renderHTML(user string, results []RepoResult, headlineHTML, summaryHTML str
 			}
 			archCs += "</ul>"
 		}
+		repoType := "—"
+		if r.Class.Type != "" {
+			repoType = fmt.Sprintf(`<span class="inline-block bg-slate-100 text-slate-700 text-xs font-semibold px-2 py-0.5 rounded-full" title="%s">%s</span>`,
+				html.EscapeString(r.Class.Reason), html.EscapeString(r.Class.Type.Label()))
+		}
 		archRows.WriteString(fmt.Sprintf(
 			`<tr class="border-b">
 <td class="py-2 px-3 font-medium align-top">%s/%s</td>
+<td class="py-2 px-3 align-top">%s</td>
+<td class="py-2 px-3">%s</td>
 <td class="py-2 px-3">%s</td>
+</tr>`,
+			html.EscapeString(r.Repo.Owner), html.EscapeString(r.Repo.Name), repoType, archSs, archCs,
+		))
+
+		// History Analysis Row
+		h := r.History
+		msgNotes := "—"
+		if len(h.MessageNotes) > 0 {
+			msgNotes = "<ul>"
+			for _, n := range h.MessageNotes {
+				msgNotes += "<li>" + html.EscapeString(n) + "</li>"
+			}
+			msgNotes += "</ul>"
+		}
+		historyRows.WriteString(fmt.Sprintf(
+			`<tr class="border-b">
+<td class="py-2 px-3 font-medium align-top">%s/%s</td>
+<td class="py-2 px-3 text-right align-top">%d</td>
+<td class="py-2 px-3 text-right align-top">%.1f</td>
+<td class="py-2 px-3 text-right align-top">%.0f%%</td>
+<td class="py-2 px-3 text-right align-top">%s</td>
+<td class="py-2 px-3 text-right align-top">%.0f</td>
+<td class="py-2 px-3 text-right align-top">%d <span class='text-xs text-slate-500'>(%dd ago)</span></td>
 <td class="py-2 px-3">%s</td>
 </tr>`,
-			html.EscapeString(r.Repo.Owner), html.EscapeString(r.Repo.Name), archSs, archCs,
+			html.EscapeString(r.Repo.Owner), html.EscapeString(r.Repo.Name),
+			h.Commits, h.CommitsPerWeek, h.ConventionalRatio*100, h.messageQuality(), h.AvgChangeSize, h.RecentCommits, h.DaysSinceLast, msgNotes,
 		))
+
+		// Documentation Row
+		if d := r.Docs; len(d.Files) > 0 {
+			notes := "—"
+			if len(d.Notes) > 0 {
+				notes = "<ul>"
+				for _, n := range d.Notes {
+					notes += "<li>" + html.EscapeString(n) + "</li>"
+				}
+				notes += "</ul>"
+			}
+			docsRows.WriteString(fmt.Sprintf(
+				`<tr class="border-b">
+<td class="py-2 px-3 font-medium align-top">%s/%s<div class="text-xs text-slate-500">%s</div></td>
+<td class="py-2 px-3 text-right align-top">%s</td>
+<td class="py-2 px-3 text-right align-top">%s</td>
+<td class="py-2 px-3 text-right align-top">%s</td>
+<td class="py-2 px-3 text-right align-top">%s</td>
+<td class="py-2 px-3">%s</td>
+</tr>`,
+				html.EscapeString(r.Repo.Owner), html.EscapeString(r.Repo.Name), html.EscapeString(strings.Join(d.Files, ", ")),
+				d.score(), d.dimension(d.Setup), d.dimension(d.Usage), d.dimension(d.Architecture), notes,
+			))
+		} else {
+			docsRows.WriteString(fmt.Sprintf(
+				`<tr class="border-b">
+<td class="py-2 px-3 font-medium align-top">%s/%s</td>
+<td class="py-2 px-3 text-slate-500" colspan="5">No README or top-level docs found.</td>
+</tr>`,
+				html.EscapeString(r.Repo.Owner), html.EscapeString(r.Repo.Name),
+			))
+		}
+
+		// Repository Health Row
+		healthRows.WriteString(fmt.Sprintf(
+			`<tr class="border-b">
+<td class="py-2 px-3 font-medium align-top">%s/%s</td>
+<td class="py-2 px-3 text-right align-top">%d%%</td>
+<td class="py-2 px-3">%s</td>
+</tr>`,
+			html.EscapeString(r.Repo.Owner), html.EscapeString(r.Repo.Name), r.Health.Score, healthBadges(r.Health),
+		))
+
+		// Static Analysis Row
+		if st := r.Static; st.Analyzed {
+			staticRows.WriteString(fmt.Sprintf(
+				`<tr class="border-b">
+<td class="py-2 px-3 font-medium align-top">%s/%s</td>
+<td class="py-2 px-3 text-right align-top">%d</td>
+<td class="py-2 px-3 text-right align-top">%.1f <span class='text-xs text-slate-500'>(max %d)</span></td>
+<td class="py-2 px-3 text-right align-top">%.0f <span class='text-xs text-slate-500'>(%d long)</span></td>
+<td class="py-2 px-3 text-right align-top">%d/%d</td>
+<td class="py-2 px-3 text-right align-top">%s</td>
+<td class="py-2 px-3 text-right align-top">%.1f</td>
+<td class="py-2 px-3 text-right align-top">%d/%d</td>
+</tr>`,
+				html.EscapeString(r.Repo.Owner), html.EscapeString(r.Repo.Name), st.Score,
+				st.AvgComplexity, st.MaxComplexity, st.AvgFuncLines, st.LongFuncs,
+				st.ExportedNoDoc, st.Exported, ignoredErrorsCell(st), st.AvgFanOut, st.GofmtConformant, st.GofmtFiles,
+			))
+		}
+
+		// Dependencies Row
+		if d := r.Deps; len(d.Manifests) > 0 {
+			findings := "—"
+			if len(d.Findings) > 0 {
+				findings = "<ul>"
+				for _, f := range d.Findings {
+					class := "text-amber-700"
+					if f.Kind == findingVulnerable {
+						class = "text-red-700"
+					}
+					label := f.Kind
+					if f.ID != "" {
+						label = f.ID
+					}
+					findings += fmt.Sprintf("<li><code>%s@%s</code> <span class='text-xs %s'>%s</span> <span class='text-xs text-slate-500'>%s</span></li>",
+						html.EscapeString(f.Name), html.EscapeString(f.Version), class, html.EscapeString(label), html.EscapeString(f.Summary))
+				}
+				findings += "</ul>"
+			}
+			depsRows.WriteString(fmt.Sprintf(
+				`<tr class="border-b">
+<td class="py-2 px-3 font-medium align-top">%s/%s</td>
+<td class="py-2 px-3 text-right align-top">%d</td>
+<td class="py-2 px-3 align-top text-xs">%s</td>
+<td class="py-2 px-3">%s</td>
+</tr>`,
+				html.EscapeString(r.Repo.Owner), html.EscapeString(r.Repo.Name), len(d.Deps),
+				html.EscapeString(strings.Join(d.Manifests, ", ")), findings,
+			))
+		}
+
+		// Excluded Files Appendix
+		if len(r.Excluded) > 0 {
+			var items strings.Builder
+			for _, e := range r.Excluded {
+				items.WriteString(fmt.Sprintf("<li><code>%s</code> <span class='text-xs text-slate-500'>(%s)</span></li>", html.EscapeString(e.Path), html.EscapeString(e.Reason)))
+			}
+			excludedList.WriteString(fmt.Sprintf(
+				`<details class="mb-2"><summary class="cursor-pointer font-medium">%s/%s <span class="text-slate-500">(%d files)</span></summary><ul class="ml-6 mt-1 list-disc">%s</ul></details>`,
+				html.EscapeString(r.Repo.Owner), html.EscapeString(r.Repo.Name), len(r.Excluded), items.String(),
+			))
+		}
+
+		// Redaction Findings Appendix
+		if len(r.Redactions) > 0 {
+			var items strings.Builder
+			for _, f := range r.Redactions {
+				items.WriteString(fmt.Sprintf("<li><code>%s:%d</code> <span class='text-xs text-red-700'>%s</span></li>", html.EscapeString(f.Path), f.Line, html.EscapeString(f.Kind)))
+			}
+			redactedList.WriteString(fmt.Sprintf(
+				`<details class="mb-2"><summary class="cursor-pointer font-medium">%s/%s <span class="text-slate-500">(%d findings)</span></summary><ul class="ml-6 mt-1 list-disc">%s</ul></details>`,
+				html.EscapeString(r.Repo.Owner), html.EscapeString(r.Repo.Name), len(r.Redactions), items.String(),
+			))
+		}
+	}
+
+	dimensionSection := renderDimensions(results, meta.Rubric)
+
+	var calibrationLine string
+	if meta.Calibration != "" {
+		calibrationLine = fmt.Sprintf("<p>Calibration: %s</p>", html.EscapeString(meta.Calibration))
+	}
+
+	var commits []string
+	for _, r := range results {
+		if r.SHA != "" {
+			commits = append(commits, fmt.Sprintf("%s/%s@%s", r.Repo.Owner, r.Repo.Name, r.SHA))
+		}
+	}
+	var commitsLine string
+	if len(commits) > 0 {
+		commitsLine = fmt.Sprintf(`<p>Commits: <code>%s</code></p>`, html.EscapeString(strings.Join(commits, " ")))
+	}
+
+	var promptItems strings.Builder
+	for _, p := range meta.Prompts {
+		promptItems.WriteString("<li><code>" + html.EscapeString(p.String()) + "</code></li>")
+	}
+	var promptsLine string
+	if len(meta.Prompts) > 0 {
+		promptsLine = fmt.Sprintf(`<details><summary class="cursor-pointer">Prompts (%d)</summary><ul class="ml-6 mt-1 list-disc">%s</ul></details>`, len(meta.Prompts), promptItems.String())
+	}
+
+	var staticSection string
+	if staticRows.Len() > 0 {
+		staticSection = fmt.Sprintf(`<section class="mt-8">
+  <h2 class="text-xl font-semibold mb-4">Static Analysis (Go)</h2>
+  <div class="bg-white shadow rounded-xl overflow-hidden">
+    <table class="w-full text-sm">
+      <thead class="bg-slate-100">
+        <tr>
+          <th class="text-left py-2 px-3 w-1/4">Repo</th>
+          <th class="text-right py-2 px-3">Score</th>
+          <th class="text-right py-2 px-3">Complexity</th>
+          <th class="text-right py-2 px-3">Func Lines</th>
+          <th class="text-right py-2 px-3">Undocumented</th>
+          <th class="text-right py-2 px-3">Ignored Errors</th>
+          <th class="text-right py-2 px-3">Fan-out</th>
+          <th class="text-right py-2 px-3">gofmt</th>
+        </tr>
+      </thead>
+      <tbody>
+        %s
+      </tbody>
+    </table>
+  </div>
+</section>`, staticRows.String())
+	}
+
+	var depsSection string
+	if depsRows.Len() > 0 {
+		depsSection = fmt.Sprintf(`<section class="mt-8">
+  <h2 class="text-xl font-semibold mb-4">Dependencies</h2>
+  <div class="bg-white shadow rounded-xl overflow-hidden">
+    <table class="w-full text-sm">
+      <thead class="bg-slate-100">
+        <tr>
+          <th class="text-left py-2 px-3 w-1/4">Repo</th>
+          <th class="text-right py-2 px-3">Direct</th>
+          <th class="text-left py-2 px-3">Manifests</th>
+          <th class="text-left py-2 px-3">Vulnerable or Outdated</th>
+        </tr>
+      </thead>
+      <tbody>
+        %s
+      </tbody>
+    </table>
+  </div>
+</section>`, depsRows.String())
+	}
+
+	var redactedSection string
+	if redactedList.Len() > 0 {
+		redactedSection = fmt.Sprintf(`<section class="mt-8">
+  <h2 class="text-lg font-semibold mb-2">Appendix: Redacted Secrets and PII</h2>
+  <p class="text-sm text-slate-600 mb-2">These values were replaced with placeholders before any code was sent to the LLM.</p>
+  <div class="bg-white shadow rounded-xl p-4 text-sm">%s</div>
+</section>`, redactedList.String())
+	}
+
+	var excludedSection string
+	if excludedList.Len() > 0 {
+		excludedSection = fmt.Sprintf(`<section class="mt-8">
+  <h2 class="text-lg font-semibold mb-2">Appendix: Excluded Files</h2>
+  <p class="text-sm text-slate-600 mb-2">Generated, vendored, minified and lock files were not reviewed.</p>
+  <div class="bg-white shadow rounded-xl p-4 text-sm">%s</div>
+</section>`, excludedList.String())
 	}
 
 	return fmt.Sprintf(`<!doctype html>
@@ -113,16 +356,18 @@ func renderHTML(user string, results []RepoResult, headlineHTML, summaryHTML str
 <head>
 <meta charset="utf-8">
 <meta name="viewport" content="width=device-width,initial-scale=1">
-<title>GitHub Profiller – @%s</title>
+<title>%sGitHub Profiller – @%s</title>
 <script src="https://cdn.tailwindcss.com"></script>
 </head>
 <body class="bg-slate-50 text-slate-900">
 <main class="max-w-5xl mx-auto p-6">
+%s
 <header class="mb-6">
   <h1 class="text-2xl font-bold">GitHub Profiller for <a href="https://github.com/%s" class="text-blue-600 hover:underline" target="_blank" rel="noreferrer">@%s</a></h1>
   <p class="text-sm text-slate-600">Generated locally. Scores are LLM-assisted and based on sampled files.</p>
 </header>
 
+%s
 %s
 
 <section class="mt-8">
@@ -135,6 +380,7 @@ func renderHTML(user string, results []RepoResult, headlineHTML, summaryHTML str
           <th class="text-right py-2 px-3">Score</th>
           <th class="text-left py-2 px-3">Strengths</th>
           <th class="text-left py-2 px-3">Risks</th>
+          <th class="text-left py-2 px-3">Testing</th>
           <th class="text-left py-2 px-3">Samples</th>
         </tr>
       </thead>
@@ -145,6 +391,8 @@ func renderHTML(user string, results []RepoResult, headlineHTML, summaryHTML str
   </div>
 </section>
 
+%s
+
 <section class="mt-8">
   <h2 class="text-xl font-semibold mb-4">Architecture Analysis</h2>
   <div class="bg-white shadow rounded-xl overflow-hidden">
@@ -152,6 +400,7 @@ func renderHTML(user string, results []RepoResult, headlineHTML, summaryHTML str
       <thead class="bg-slate-100">
         <tr>
           <th class="text-left py-2 px-3 w-1/4">Repo</th>
+          <th class="text-left py-2 px-3">Type</th>
           <th class="text-left py-2 px-3">Strengths</th>
           <th class="text-left py-2 px-3">Considerations</th>
         </tr>
@@ -163,10 +412,348 @@ func renderHTML(user string, results []RepoResult, headlineHTML, summaryHTML str
   </div>
 </section>
 
+<section class="mt-8">
+  <h2 class="text-xl font-semibold mb-4">History &amp; Hygiene</h2>
+  <div class="bg-white shadow rounded-xl overflow-hidden">
+    <table class="w-full text-sm">
+      <thead class="bg-slate-100">
+        <tr>
+          <th class="text-left py-2 px-3 w-1/4">Repo</th>
+          <th class="text-right py-2 px-3">Commits</th>
+          <th class="text-right py-2 px-3">Per Week</th>
+          <th class="text-right py-2 px-3">Conventional</th>
+          <th class="text-right py-2 px-3">Messages</th>
+          <th class="text-right py-2 px-3">Avg Change</th>
+          <th class="text-right py-2 px-3">Last 90d</th>
+          <th class="text-left py-2 px-3">Notes</th>
+        </tr>
+      </thead>
+      <tbody>
+        %s
+      </tbody>
+    </table>
+  </div>
+</section>
+
+<section class="mt-8">
+  <h2 class="text-xl font-semibold mb-4">Documentation</h2>
+  <div class="bg-white shadow rounded-xl overflow-hidden">
+    <table class="w-full text-sm">
+      <thead class="bg-slate-100">
+        <tr>
+          <th class="text-left py-2 px-3 w-1/4">Repo</th>
+          <th class="text-right py-2 px-3">Score</th>
+          <th class="text-right py-2 px-3">Setup</th>
+          <th class="text-right py-2 px-3">Usage</th>
+          <th class="text-right py-2 px-3">Architecture</th>
+          <th class="text-left py-2 px-3">Notes</th>
+        </tr>
+      </thead>
+      <tbody>
+        %s
+      </tbody>
+    </table>
+  </div>
+</section>
+
+<section class="mt-8">
+  <h2 class="text-xl font-semibold mb-4">Repository Health</h2>
+  <div class="bg-white shadow rounded-xl overflow-hidden">
+    <table class="w-full text-sm">
+      <thead class="bg-slate-100">
+        <tr>
+          <th class="text-left py-2 px-3 w-1/4">Repo</th>
+          <th class="text-right py-2 px-3">Health</th>
+          <th class="text-left py-2 px-3">Checklist</th>
+        </tr>
+      </thead>
+      <tbody>
+        %s
+      </tbody>
+    </table>
+  </div>
+</section>
+
+%s
+
+%s
+
+%s
 %s
 %s
+%s
+
+<footer class="mt-8 pt-4 border-t text-xs text-slate-500">
+  <p>Sampling: %s (seed %d)</p>
+  %s
+  <p>Rubric: %s</p>
+  <p>Scoring: <code>%s</code></p>
+  %s
+  %s
+</footer>
 
 </main>
 </body>
-</html>`, html.EscapeString(user), html.EscapeString(user), html.EscapeString(user), headlineHTML, codeRows.String(), archRows.String(), summaryHTML, langSection)
+</html>`, titlePrefix, html.EscapeString(user), watermark, html.EscapeString(user), html.EscapeString(user), headlineHTML, estimateSection(meta), codeRows.String(), dimensionSection, archRows.String(), historyRows.String(), docsRows.String(), healthRows.String(), staticSection, depsSection, summaryHTML, langSection, excludedSection, redactedSection, html.EscapeString(meta.Sampling), meta.Seed, commitsLine, html.EscapeString(meta.Rubric.Name), html.EscapeString(meta.Formula), calibrationLine, promptsLine)
+}
+
+// noteList renders labeled notes with their category and severity.
+func noteList(notes []Note) string {
+	if len(notes) == 0 {
+		return "—"
+	}
+
+	var b strings.Builder
+	b.WriteString("<ul>")
+	for _, n := range notes {
+		b.WriteString("<li>" + html.EscapeString(n.Text))
+		var tags []string
+		if n.Category != "" {
+			tags = append(tags, n.Category)
+		}
+		if n.Kind == noteRisk && n.Severity != "" {
+			tags = append(tags, n.Severity)
+		}
+		if n.Count > 1 {
+			tags = append(tags, fmt.Sprintf("×%d", n.Count))
+		}
+		if len(tags) > 0 {
+			class := "text-slate-500"
+			if n.Kind == noteRisk && n.Severity == "high" {
+				class = "text-red-700"
+			}
+			b.WriteString(fmt.Sprintf(" <span class='text-xs %s'>(%s)</span>", class, html.EscapeString(strings.Join(tags, ", "))))
+		}
+		b.WriteString("</li>")
+	}
+	b.WriteString("</ul>")
+	return b.String()
+}
+
+// scoreCell shows the score with its interval and coverage, greyed out
+// when the evidence behind it is thin.
+func scoreCell(score int, c Confidence, cal *Calibrated) string {
+	class, title := "font-semibold", ""
+	if c.LowConfidence {
+		class, title = "text-slate-400", "Low confidence: "+c.Reason
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`<div class="%s" title="%s">%d</div>`, class, html.EscapeString(title), score))
+	if c.High > 0 {
+		b.WriteString(fmt.Sprintf("<div class='text-xs text-slate-500 whitespace-nowrap'>%d–%d</div>", c.Low, c.High))
+	}
+	if c.SourceFiles > 0 {
+		b.WriteString(fmt.Sprintf("<div class='text-xs text-slate-500 whitespace-nowrap' title='Source files sampled'>%d/%d files (%.0f%%)</div>", c.SampledFiles, c.SourceFiles, c.Coverage*100))
+	}
+	if cal != nil {
+		b.WriteString(fmt.Sprintf("<div class='text-xs text-sky-700 whitespace-nowrap'>p%.0f · %s</div>", cal.Percentile, html.EscapeString(cal.Level)))
+	}
+	if c.LowConfidence {
+		b.WriteString("<div class='text-xs text-amber-700'>low confidence</div>")
+	}
+	return b.String()
+}
+
+// estimateSection shows the calibrated profile level, if there is one.
+func estimateSection(meta ReportMeta) string {
+	if meta.Estimate == nil {
+		return ""
+	}
+	return fmt.Sprintf(`<section class="mb-8 p-4 bg-white shadow rounded-xl">
+  <p class="text-lg">Estimated level: <span class="font-semibold capitalize">%s</span> <span class="text-sm text-slate-500">(%s percentile of the benchmark set, median repo)</span></p>
+  <p class="text-xs text-slate-500">Calibration %s</p>
+</section>`, html.EscapeString(meta.Estimate.Level), ordinal(int(meta.Estimate.Percentile+0.5)), html.EscapeString(meta.Calibration))
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+func testingCell(t TestingMetrics) string {
+	if t.SourceFiles == 0 {
+		return "—"
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("<div>%d tests / %d sources <span class='text-xs text-slate-500'>(%.2f)</span></div>", t.TestFiles, t.SourceFiles, t.Ratio))
+	b.WriteString(fmt.Sprintf("<div class='text-xs text-slate-500'>%d/%d packages untested</div>", len(t.UntestedPackages), t.Packages))
+	if len(t.Frameworks) > 0 {
+		b.WriteString(fmt.Sprintf("<div class='text-xs'>%s</div>", html.EscapeString(strings.Join(t.Frameworks, ", "))))
+	}
+	if t.TableDriven > 0 {
+		b.WriteString(fmt.Sprintf("<div class='text-xs'>%d table-driven</div>", t.TableDriven))
+	}
+	if len(t.ReviewedFiles) > 0 {
+		if t.Reviewed {
+			b.WriteString(fmt.Sprintf("<div class='text-xs'>Review: %d/5</div>", t.ReviewScore))
+		} else {
+			b.WriteString("<div class='text-xs'>Review: n/a</div>")
+		}
+		if len(t.ReviewNotes) > 0 {
+			b.WriteString("<ul class='text-xs'>")
+			for i, n := range t.ReviewNotes {
+				if i >= 3 {
+					break
+				}
+				b.WriteString("<li>" + html.EscapeString(n) + "</li>")
+			}
+			b.WriteString("</ul>")
+		}
+	}
+	return b.String()
+}
+
+// ignoredErrorsCell flags counts that miss standard library calls, which
+// could not be type-checked, so they are not read as clean.
+func ignoredErrorsCell(st StaticMetrics) string {
+	if st.StdUnresolved == 0 {
+		return fmt.Sprintf("%d", st.IgnoredErrors)
+	}
+	return fmt.Sprintf(`%d <span class='text-xs text-amber-700' title='%d standard library packages could not be type-checked'>(incomplete)</span>`,
+		st.IgnoredErrors, st.StdUnresolved)
+}
+
+func healthBadges(h RepoHealth) string {
+	var b strings.Builder
+	for _, c := range h.Checks {
+		class := "bg-slate-100 text-slate-400 line-through"
+		if c.Present {
+			class = "bg-green-100 text-green-800"
+		}
+		b.WriteString(fmt.Sprintf(`<span class="inline-block %s text-xs font-semibold px-2 py-0.5 rounded-full mr-1 mb-1" title="%s">%s</span>`,
+			class, html.EscapeString(c.Detail), html.EscapeString(c.Name)))
+	}
+	return b.String()
+}
+
+// renderDimensions shows the rubric dimension scores of each repo and of
+// the whole profile, with a radar chart of the profile over the repos.
+func renderDimensions(results []RepoResult, rubric Rubric) string {
+	profile := profileDimensions(results)
+	if profile == nil {
+		return ""
+	}
+
+	keys, scale := rubric.Keys(), float64(rubric.Scale)
+	var head, rows strings.Builder
+	for _, d := range rubric.Dimensions {
+		head.WriteString(fmt.Sprintf(`<th class="text-right py-2 px-3 capitalize" title="%s">%s</th>`,
+			html.EscapeString(d.Description), html.EscapeString(strings.ReplaceAll(d.Key, "_", " "))))
+	}
+	var series [][]float64
+	for _, r := range results {
+		if len(r.Dimensions) == 0 {
+			continue
+		}
+		rows.WriteString(fmt.Sprintf(`<tr class="border-b"><td class="py-2 px-3 font-medium">%s/%s</td>%s</tr>`,
+			html.EscapeString(r.Repo.Owner), html.EscapeString(r.Repo.Name), dimensionCells(r.Dimensions, keys, scale)))
+		series = append(series, dimensionSeries(r.Dimensions, keys))
+	}
+	rows.WriteString(fmt.Sprintf(`<tr class="bg-slate-50 font-semibold"><td class="py-2 px-3">Profile</td>%s</tr>`, dimensionCells(profile, keys, scale)))
+
+	return fmt.Sprintf(`<section class="mt-8">
+  <h2 class="text-xl font-semibold mb-4">Dimensions <span class="text-sm font-normal text-slate-500">(%s rubric, 0-%d)</span></h2>
+  <div class="bg-white shadow rounded-xl p-4 flex flex-wrap gap-6 items-start">
+    %s
+    <table class="flex-1 text-sm">
+      <thead class="bg-slate-100">
+        <tr>
+          <th class="text-left py-2 px-3">Repo</th>
+          %s
+        </tr>
+      </thead>
+      <tbody>
+        %s
+      </tbody>
+    </table>
+  </div>
+</section>`, html.EscapeString(rubric.Name), rubric.Scale, radarSVG(keys, scale, dimensionSeries(profile, keys), series), head.String(), rows.String())
+}
+
+func dimensionCells(dims map[string]float64, keys []string, scale float64) string {
+	var b strings.Builder
+	for _, d := range keys {
+		v := dims[d]
+		class := ""
+		switch {
+		case v >= 0.8*scale:
+			class = " text-green-700"
+		case v < 0.5*scale:
+			class = " text-red-700"
+		}
+		b.WriteString(fmt.Sprintf(`<td class="py-2 px-3 text-right%s">%.1f</td>`, class, v))
+	}
+	return b.String()
+}
+
+func dimensionSeries(dims map[string]float64, keys []string) []float64 {
+	out := make([]float64, len(keys))
+	for i, d := range keys {
+		out[i] = dims[d]
+	}
+	return out
+}
+
+// radarSVG draws a 0-scale radar chart: grid rings, one faint polygon per
+// repo and the profile polygon on top.
+func radarSVG(axes []string, scale float64, profile []float64, repos [][]float64) string {
+	const size, c, radius = 300.0, 150.0, 100.0
+	n := len(axes)
+	if n < 3 {
+		return "" // No area to draw
+	}
+	point := func(i int, v float64) (float64, float64) {
+		a := 2*math.Pi*float64(i)/float64(n) - math.Pi/2
+		r := radius * v / scale
+		return c + r*math.Cos(a), c + r*math.Sin(a)
+	}
+	polygon := func(vals []float64) string {
+		pts := make([]string, len(vals))
+		for i, v := range vals {
+			x, y := point(i, v)
+			pts[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+		}
+		return strings.Join(pts, " ")
+	}
+
+	var b strings.Builder
+	// Padded sideways so the axis labels fit
+	b.WriteString(fmt.Sprintf(`<svg viewBox="-60 0 %g %g" width="%g" height="%g" class="shrink-0" role="img" aria-label="Dimension radar chart">`, size+120, size, size+120, size))
+	for ring := 1; ring <= 5; ring++ {
+		vals := make([]float64, n)
+		for i := range vals {
+			vals[i] = scale * float64(ring) / 5
+		}
+		b.WriteString(fmt.Sprintf(`<polygon points="%s" fill="none" stroke="#e2e8f0"/>`, polygon(vals)))
+	}
+	for i, a := range axes {
+		x, y := point(i, scale)
+		b.WriteString(fmt.Sprintf(`<line x1="%g" y1="%g" x2="%.1f" y2="%.1f" stroke="#e2e8f0"/>`, c, c, x, y))
+		lx, ly := point(i, scale*1.22)
+		anchor := "middle"
+		switch {
+		case lx < c-1:
+			anchor = "end"
+		case lx > c+1:
+			anchor = "start"
+		}
+		b.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" font-size="10" fill="#475569" text-anchor="%s" dominant-baseline="middle">%s</text>`, lx, ly, anchor, html.EscapeString(a)))
+	}
+	for _, r := range repos {
+		b.WriteString(fmt.Sprintf(`<polygon points="%s" fill="none" stroke="#94a3b8" stroke-opacity="0.5"/>`, polygon(r)))
+	}
+	b.WriteString(fmt.Sprintf(`<polygon points="%s" fill="#2563eb" fill-opacity="0.25" stroke="#2563eb" stroke-width="2"/>`, polygon(profile)))
+	b.WriteString(`</svg>`)
+	return b.String()
 }
@@ -0,0 +1,287 @@
+package ghp
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// RepoType is the kind of project a repository holds. It picks the
+// architecture prompt, prompts/arch_<type>.txt.
+type RepoType string
+
+const (
+	RepoMonorepo   RepoType = "monorepo"
+	RepoInfra      RepoType = "infra"
+	RepoNotebook   RepoType = "notebook"
+	RepoFrontend   RepoType = "frontend"
+	RepoWebService RepoType = "web-service"
+	RepoCLI        RepoType = "cli"
+	RepoLibrary    RepoType = "library"
+	RepoStandard   RepoType = "standard"
+)
+
+var repoTypeLabels = map[RepoType]string{
+	RepoMonorepo:   "Monorepo",
+	RepoInfra:      "Infra / IaC",
+	RepoNotebook:   "Notebook / data",
+	RepoFrontend:   "Frontend app",
+	RepoWebService: "Web service",
+	RepoCLI:        "CLI",
+	RepoLibrary:    "Library",
+	RepoStandard:   "Application",
+}
+
+// Label is the name shown in the report.
+func (t RepoType) Label() string {
+	if l, ok := repoTypeLabels[t]; ok {
+		return l
+	}
+	return string(t)
+}
+
+func (t RepoType) archPrompt() string {
+	return "prompts/arch_" + strings.ReplaceAll(string(t), "-", "_") + ".txt"
+}
+
+// RepoClass is the type of a repository and the signal that decided it.
+type RepoClass struct {
+	Type   RepoType
+	Reason string
+}
+
+// repoTypeRule classifies a tree as Type when match returns a reason.
+type repoTypeRule struct {
+	Type  RepoType
+	match func(treeSignals) string
+}
+
+// repoTypes are tried in order and the first match wins; trees no rule
+// matches are RepoStandard. A new type needs a rule here, a label and its
+// architecture prompt.
+var repoTypes = []repoTypeRule{
+	{RepoMonorepo, matchMonorepo},
+	{RepoInfra, matchInfra},
+	{RepoNotebook, matchNotebook},
+	{RepoFrontend, matchFrontend},
+	{RepoWebService, matchWebService},
+	{RepoCLI, matchCLI},
+	{RepoLibrary, matchLibrary},
+}
+
+// classifyRepo picks the repository type from its file tree.
+func classifyRepo(tree []string) RepoClass {
+	t := newTreeSignals(tree)
+	for _, r := range repoTypes {
+		if reason := r.match(t); reason != "" {
+			return RepoClass{Type: r.Type, Reason: reason}
+		}
+	}
+	return RepoClass{Type: RepoStandard, Reason: "no specific project markers"}
+}
+
+// treeSignals is a file tree prepared for the classifier rules.
+type treeSignals struct {
+	paths  []string // Lowercased
+	files  map[string]bool
+	exts   map[string]int
+	source int // Source files, tests excluded
+}
+
+func newTreeSignals(tree []string) treeSignals {
+	t := treeSignals{files: make(map[string]bool), exts: make(map[string]int)}
+	for _, p := range tree {
+		l := strings.ToLower(p)
+		t.paths = append(t.paths, l)
+		t.files[l] = true
+		t.exts[path.Ext(l)]++
+		if isSourcePath(l) && !isTestPath(l) {
+			t.source++
+		}
+	}
+	return t
+}
+
+// find returns the first path match accepts, or "".
+func (t treeSignals) find(match func(p, base string) bool) string {
+	for _, p := range t.paths {
+		if match(p, path.Base(p)) {
+			return p
+		}
+	}
+	return ""
+}
+
+func (t treeSignals) count(match func(p, base string) bool) int {
+	var n int
+	for _, p := range t.paths {
+		if match(p, path.Base(p)) {
+			n++
+		}
+	}
+	return n
+}
+
+var workspaceMarkers = map[string]bool{
+	"lerna.json": true, "turbo.json": true, "nx.json": true, "pnpm-workspace.yaml": true, "rush.json": true,
+}
+
+func matchMonorepo(t treeSignals) string {
+	if p := t.find(func(_, base string) bool { return workspaceMarkers[base] }); p != "" {
+		return "workspace config " + p
+	}
+	apps := t.find(func(p, _ string) bool { return strings.HasPrefix(p, "apps/") })
+	pkgs := t.find(func(p, _ string) bool { return strings.HasPrefix(p, "packages/") || strings.HasPrefix(p, "libs/") })
+	if apps != "" && pkgs != "" {
+		return "apps/ next to packages/ or libs/"
+	}
+	// Allow for a root and one sub-module without calling it a monorepo
+	if n := t.count(func(_, base string) bool { return base == "go.mod" }); n > 2 {
+		return fmt.Sprintf("%d go.mod files", n)
+	}
+	return ""
+}
+
+func isIaC(p, base string) bool {
+	switch {
+	case strings.HasSuffix(base, ".tf"), strings.HasSuffix(base, ".tfvars"), strings.HasSuffix(base, ".hcl"):
+		return true
+	case base == "chart.yaml", base == "kustomization.yaml", base == "kustomization.yml", base == "pulumi.yaml", base == "cdk.json":
+		return true
+	case strings.HasPrefix(base, "playbook") && (strings.HasSuffix(base, ".yml") || strings.HasSuffix(base, ".yaml")):
+		return true
+	case strings.Contains(p, "roles/") && strings.HasSuffix(p, "/tasks/main.yml"):
+		return true
+	}
+	return false
+}
+
+func matchInfra(t treeSignals) string {
+	n := t.count(isIaC)
+	if n >= 3 && n >= t.source {
+		return fmt.Sprintf("%d IaC files such as %s, %d source files", n, t.find(isIaC), t.source)
+	}
+	return ""
+}
+
+func matchNotebook(t treeSignals) string {
+	if p := t.find(func(_, base string) bool { return base == "dbt_project.yml" }); p != "" {
+		return "dbt project " + p
+	}
+	if n := t.exts[".ipynb"]; n >= 2 && 2*n >= t.source {
+		return fmt.Sprintf("%d notebooks, %d source files", n, t.source)
+	}
+	return ""
+}
+
+var frontendMarkers = []string{
+	"next.config.", "vite.config.", "nuxt.config.", "svelte.config.", "vue.config.",
+	"gatsby-config.", "astro.config.", "remix.config.", "angular.json",
+}
+
+func matchFrontend(t treeSignals) string {
+	p := t.find(func(_, base string) bool {
+		for _, m := range frontendMarkers {
+			if strings.HasPrefix(base, m) {
+				return true
+			}
+		}
+		return false
+	})
+	if p != "" {
+		return "frontend build config " + p
+	}
+	return ""
+}
+
+var serviceMarkers = map[string]bool{
+	"openapi.yaml": true, "openapi.yml": true, "openapi.json": true,
+	"swagger.yaml": true, "swagger.yml": true, "swagger.json": true,
+	"manage.py": true, "wsgi.py": true, "asgi.py": true, "procfile": true,
+}
+
+var serviceDirs = map[string]bool{
+	"handlers": true, "handler": true, "routes": true, "router": true, "controllers": true,
+	"middleware": true, "endpoints": true, "resolvers": true,
+}
+
+func matchWebService(t treeSignals) string {
+	marker := t.find(func(p, base string) bool {
+		return serviceMarkers[base] || strings.Contains(p, "src/main/resources/application.")
+	})
+	if marker != "" {
+		return "service marker " + marker
+	}
+	dir := t.find(func(p, _ string) bool {
+		for _, d := range strings.Split(path.Dir(p), "/") {
+			if serviceDirs[d] {
+				return true
+			}
+		}
+		return false
+	})
+	if dir == "" {
+		return ""
+	}
+	if entry := t.find(isEntrypoint); entry != "" {
+		return fmt.Sprintf("%s with entrypoint %s", path.Dir(dir), entry)
+	}
+	return ""
+}
+
+func matchCLI(t treeSignals) string {
+	p := t.find(func(p, base string) bool {
+		switch {
+		case strings.HasPrefix(p, "cmd/") && base == "main.go":
+			return true
+		case base == "__main__.py", base == "cli.py", base == "cli.go", base == "cli.ts", base == "cli.js", base == "cli.rs":
+			return true
+		case p == "src/main.rs" && !t.files["src/lib.rs"]:
+			return true
+		case strings.HasPrefix(p, "bin/") && t.files["package.json"]:
+			return true
+		}
+		return false
+	})
+	if p != "" {
+		return "command entrypoint " + p
+	}
+	return ""
+}
+
+var libraryManifests = map[string]bool{
+	"go.mod": true, "setup.py": true, "pyproject.toml": true, "cargo.toml": true, "package.json": true,
+	"pom.xml": true, "build.gradle": true, "build.gradle.kts": true, "mix.exs": true, "pubspec.yaml": true,
+}
+
+func matchLibrary(t treeSignals) string {
+	if t.files["src/lib.rs"] {
+		return "crate root src/lib.rs"
+	}
+	manifest := t.find(func(p, base string) bool { return p == base && libraryManifests[base] })
+	if manifest != "" && t.find(isEntrypoint) == "" {
+		return manifest + " and no entrypoint"
+	}
+	return ""
+}
+
+var entrypoints = map[string]bool{
+	"main.go": true, "main.rs": true, "__main__.py": true, "main.py": true, "app.py": true,
+	"server.go": true, "server.js": true, "server.ts": true, "server.py": true, "index.html": true,
+	"program.cs": true, "application.java": true, "main.java": true, "main.dart": true,
+}
+
+// isEntrypoint matches program entrypoints outside examples and test data,
+// which libraries often ship.
+func isEntrypoint(p, base string) bool {
+	if !entrypoints[base] {
+		return false
+	}
+	for _, d := range strings.Split(path.Dir(p), "/") {
+		switch d {
+		case "example", "examples", "_examples", "testdata", "docs", "test", "tests":
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package ghp
+
+import "testing"
+
+func TestClassifyRepo(t *testing.T) {
+	tests := []struct {
+		name string
+		tree []string
+		want RepoType
+	}{
+		{"workspace config", []string{"package.json", "turbo.json", "apps/web/index.ts"}, RepoMonorepo},
+		{"apps and packages", []string{"apps/web/package.json", "packages/ui/package.json"}, RepoMonorepo},
+		{"go modules", []string{"go.mod", "a/go.mod", "b/go.mod", "cmd/x/main.go"}, RepoMonorepo},
+		{"two go modules", []string{"go.mod", "tools/go.mod", "lib.go"}, RepoLibrary},
+		{"terraform", []string{"main.tf", "variables.tf", "modules/vpc/main.tf", "scripts/x.py"}, RepoInfra},
+		{"terraform next to code", []string{"infra/main.tf", "infra/vars.tf", "infra/out.tf", "a.go", "b.go", "c.go", "d.go", "go.mod"}, RepoLibrary},
+		{"ansible", []string{"site.yml", "playbook.yml", "roles/web/tasks/main.yml", "roles/db/tasks/main.yml"}, RepoInfra},
+		{"notebooks", []string{"nb/a.ipynb", "nb/b.ipynb", "src/util.py", "requirements.txt"}, RepoNotebook},
+		{"dbt", []string{"dbt_project.yml", "models/orders.sql"}, RepoNotebook},
+		{"vite", []string{"package.json", "vite.config.ts", "src/App.tsx"}, RepoFrontend},
+		{"next before service dirs", []string{"package.json", "next.config.js", "pages/api/routes/x.ts", "server.js"}, RepoFrontend},
+		{"openapi", []string{"go.mod", "api/openapi.yaml", "lib.go"}, RepoWebService},
+		{"django", []string{"manage.py", "app/models.py"}, RepoWebService},
+		{"spring", []string{"pom.xml", "src/main/resources/application.yml", "src/main/java/App.java"}, RepoWebService},
+		{"handlers and main", []string{"go.mod", "cmd/api/main.go", "internal/handlers/user.go"}, RepoWebService},
+		{"handlers without entrypoint", []string{"go.mod", "middleware/log.go"}, RepoLibrary},
+		{"go cmd", []string{"go.mod", "cmd/tool/main.go", "internal/x.go"}, RepoCLI},
+		{"python main module", []string{"pyproject.toml", "pkg/__main__.py", "pkg/core.py"}, RepoCLI},
+		{"rust binary", []string{"Cargo.toml", "src/main.rs"}, RepoCLI},
+		{"node bin", []string{"package.json", "bin/tool", "lib/index.js"}, RepoCLI},
+		{"rust crate", []string{"Cargo.toml", "src/lib.rs", "src/main.rs"}, RepoLibrary},
+		{"go library with examples", []string{"go.mod", "lib.go", "examples/basic/main.go"}, RepoLibrary},
+		{"root main", []string{"go.mod", "main.go"}, RepoStandard},
+		{"no manifest", []string{"README.md", "notes.txt"}, RepoStandard},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyRepo(tt.tree)
+			if got.Type != tt.want {
+				t.Errorf("classifyRepo(%v) = %s (%s), want %s", tt.tree, got.Type, got.Reason, tt.want)
+			}
+			if got.Reason == "" {
+				t.Errorf("classifyRepo(%v) has no reason", tt.tree)
+			}
+		})
+	}
+}
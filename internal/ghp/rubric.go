@@ -0,0 +1,115 @@
+package ghp
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rubric is a scoring pack: the dimensions the LLM rates each chunk on,
+// on a 0..Scale scale, with guidance for each.
+type Rubric struct {
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description"`
+	Scale       int               `yaml:"scale"`
+	Dimensions  []RubricDimension `yaml:"dimensions"`
+	Hash        string            `yaml:"-"` // Of the pack source
+}
+
+type RubricDimension struct {
+	Key         string `yaml:"key"`
+	Description string `yaml:"description"`
+	Guidance    string `yaml:"guidance"`
+}
+
+const defaultRubric = "default"
+
+// loadRubric reads a rubric pack. A name ending in .yml or .yaml is read
+// from disk; any other name is an embedded pack under rubrics/.
+func loadRubric(fsys fs.FS, name string) (*Rubric, error) {
+	if name == "" {
+		name = defaultRubric
+	}
+
+	var diskPath, embedPath string
+	if strings.HasSuffix(name, ".yml") || strings.HasSuffix(name, ".yaml") {
+		diskPath = name
+	} else {
+		embedPath = "rubrics/" + name + ".yml"
+	}
+
+	src, _, err := loadPrompt(fsys, diskPath, embedPath)
+	if err != nil {
+		return nil, fmt.Errorf("rubric %q: %w", name, err)
+	}
+
+	var r Rubric
+	if err := yaml.Unmarshal([]byte(src), &r); err != nil {
+		return nil, fmt.Errorf("rubric %q: %w", name, err)
+	}
+	if err := r.validate(); err != nil {
+		return nil, fmt.Errorf("rubric %q: %w", name, err)
+	}
+	r.Hash = contentHash(src)
+
+	return &r, nil
+}
+
+func (r *Rubric) validate() error {
+	if r.Scale <= 0 {
+		r.Scale = 5
+	}
+	if len(r.Dimensions) == 0 {
+		return fmt.Errorf("no dimensions")
+	}
+	seen := map[string]bool{}
+	for _, d := range r.Dimensions {
+		if d.Key == "" {
+			return fmt.Errorf("dimension without key")
+		}
+		if seen[d.Key] {
+			return fmt.Errorf("duplicate dimension %q", d.Key)
+		}
+		seen[d.Key] = true
+	}
+	return nil
+}
+
+// Keys returns the dimension keys in pack order.
+func (r *Rubric) Keys() []string {
+	keys := make([]string, len(r.Dimensions))
+	for i, d := range r.Dimensions {
+		keys[i] = d.Key
+	}
+	return keys
+}
+
+// PromptSection lists the dimensions and their guidance for the prompt.
+func (r *Rubric) PromptSection() string {
+	var b strings.Builder
+	for _, d := range r.Dimensions {
+		b.WriteString(fmt.Sprintf("- %s (0-%d): %s", d.Key, r.Scale, d.Description))
+		if d.Guidance != "" {
+			b.WriteString(" " + d.Guidance)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// OutputFormat is the "scores" object of the JSON the prompt asks for.
+func (r *Rubric) OutputFormat() string {
+	var b strings.Builder
+	b.WriteString("{\n")
+	for i, d := range r.Dimensions {
+		sep := ","
+		if i == len(r.Dimensions)-1 {
+			sep = ""
+		}
+		b.WriteString(fmt.Sprintf("    %q: int%s  // 0-%d, %s\n", d.Key, sep, r.Scale, strings.TrimSuffix(d.Description, ".")))
+	}
+	b.WriteString("  }")
+	return b.String()
+}
@@ -0,0 +1,174 @@
+package ghp
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	samplingScore      = "score"
+	samplingStratified = "stratified"
+	samplingSize       = "size"
+	samplingRecency    = "recency"
+	samplingRandom     = "random"
+
+	// recencyCommits is how many of the latest commits feed the recency strategy.
+	recencyCommits = 30
+)
+
+var samplingStrategies = []string{samplingScore, samplingStratified, samplingSize, samplingRecency, samplingRandom}
+
+type samplingInput struct {
+	Entries []TreeEntry
+	Recent  []string // Paths touched by the latest commits, most recent first
+	Seed    int64
+}
+
+type scoredPath struct {
+	Path  string
+	Size  int
+	Score int
+}
+
+// pickPaths selects up to ChunksPerRepo files with the configured strategy.
+// Every strategy only considers files scorePath does not rule out, and ties
+// are broken by path, so the same input and seed give the same selection.
+func pickPaths(in samplingInput, cfg *Config) []string {
+	var cands []scoredPath
+	for _, e := range in.Entries {
+		if score := scorePath(e.Path); score > 0 {
+			cands = append(cands, scoredPath{Path: e.Path, Size: e.Size, Score: score})
+		}
+	}
+	byScore := func(a, b scoredPath) int {
+		if a.Score != b.Score {
+			return b.Score - a.Score
+		}
+		return strings.Compare(a.Path, b.Path)
+	}
+	slices.SortFunc(cands, byScore)
+
+	switch cfg.App.Sampling {
+	case samplingStratified:
+		cands = stratify(cands)
+	case samplingSize:
+		slices.SortFunc(cands, func(a, b scoredPath) int {
+			if sa, sb := isSourceScore(a.Score), isSourceScore(b.Score); sa != sb {
+				if sa {
+					return -1
+				}
+				return 1
+			}
+			if a.Size != b.Size {
+				return b.Size - a.Size
+			}
+			return byScore(a, b)
+		})
+	case samplingRecency:
+		rank := make(map[string]int, len(in.Recent))
+		for i, p := range in.Recent {
+			if _, ok := rank[p]; !ok {
+				rank[p] = i
+			}
+		}
+		slices.SortStableFunc(cands, func(a, b scoredPath) int {
+			ra, okA := rank[a.Path]
+			rb, okB := rank[b.Path]
+			switch {
+			case okA && okB:
+				return ra - rb
+			case okA:
+				return -1
+			case okB:
+				return 1
+			}
+			return 0
+		})
+	case samplingRandom:
+		rnd := rand.New(rand.NewSource(in.Seed))
+		rnd.Shuffle(len(cands), func(i, j int) { cands[i], cands[j] = cands[j], cands[i] })
+	}
+
+	var out []string
+	for _, sp := range cands {
+		out = append(out, sp.Path)
+		if len(out) >= cfg.App.ChunksPerRepo {
+			break
+		}
+	}
+	return out
+}
+
+// stratify interleaves files directory by directory (the package, in Go),
+// so every package gets its best file in before any gets a second one.
+// Directories are visited in the order of their best file.
+func stratify(sorted []scoredPath) []scoredPath {
+	groups := make(map[string][]scoredPath)
+	var order []string
+	for _, sp := range sorted {
+		dir := filepath.Dir(sp.Path)
+		if _, ok := groups[dir]; !ok {
+			order = append(order, dir)
+		}
+		groups[dir] = append(groups[dir], sp)
+	}
+
+	out := make([]scoredPath, 0, len(sorted))
+	for round := 0; len(out) < len(sorted); round++ {
+		for _, dir := range order {
+			if round < len(groups[dir]) {
+				out = append(out, groups[dir][round])
+			}
+		}
+	}
+	return out
+}
+
+// isSourceScore reports whether scorePath rated the file as source code.
+func isSourceScore(score int) bool {
+	return score >= 55
+}
+
+// repoSeed derives a per-repo seed from the run seed, so each repo gets its
+// own shuffle and adding a repo to a run does not change the others.
+func repoSeed(seed int64, repo RepoTarget) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(repo.Owner + "/" + repo.Name))
+	return seed ^ int64(h.Sum64())
+}
+
+// recentPaths lists the files touched by the latest commits, newest first.
+func (s *service) recentPaths(ctx context.Context, repo RepoTarget, sha string) []string {
+	commits, err := s.gh.ListCommits(ctx, repo.Owner, repo.Name, repo.DefaultBranch, sha, s.cfg.App.HistoryCommits)
+	if err != nil {
+		fmt.Printf("warn: could not list commits for %s/%s: %v\n", repo.Owner, repo.Name, err)
+		return nil
+	}
+
+	var paths []string
+	for i, c := range commits {
+		if i >= recencyCommits {
+			break
+		}
+		files, err := s.gh.ListCommitFiles(ctx, repo.Owner, repo.Name, c.SHA)
+		if err != nil {
+			fmt.Printf("warn: could not list files of %s in %s/%s: %v\n", c.SHA, repo.Owner, repo.Name, err)
+			continue
+		}
+		paths = append(paths, files...)
+	}
+	return paths
+}
+
+func treePaths(entries []TreeEntry) []string {
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = e.Path
+	}
+	return paths
+}
@@ -0,0 +1,319 @@
+package ghp
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Scoring configures how chunk scores become a repo score. A chunk scores
+// the weighted mean of its rubric dimensions; each chunk is then weighted by
+// 1 plus every matching path weight, and the aggregation combines them.
+type Scoring struct {
+	Rubric      string             `yaml:"rubric"`     // Embedded pack name or path to a YAML pack
+	Dimensions  map[string]float64 `yaml:"dimensions"` // Unlisted dimensions weigh 1
+	Paths       []PathWeight       `yaml:"paths"`
+	Aggregation string             `yaml:"aggregation"`
+	TrimRatio   float64            `yaml:"trim_ratio"` // Share dropped from each end by trimmed_mean
+	// A score is low confidence with fewer scored chunks than MinChunks or
+	// a 95% interval wider than MaxInterval points.
+	MinChunks   int `yaml:"min_chunks"`
+	MaxInterval int `yaml:"max_interval"`
+
+	rubric *Rubric
+}
+
+// Confidence qualifies a repo score: the bootstrap interval over its chunk
+// scores and how much of the source tree the sample covered.
+type Confidence struct {
+	Low, High     int
+	SampledFiles  int
+	SourceFiles   int
+	Coverage      float64
+	LowConfidence bool
+	Reason        string
+}
+
+// PathWeight adds Weight to chunks whose path matches the Pattern regexp.
+type PathWeight struct {
+	Pattern string  `yaml:"pattern"`
+	Weight  float64 `yaml:"weight"`
+
+	re *regexp.Regexp
+}
+
+const (
+	aggregateMean        = "mean"
+	aggregateTrimmedMean = "trimmed_mean"
+	aggregateMedian      = "median"
+)
+
+var aggregations = []string{aggregateMean, aggregateTrimmedMean, aggregateMedian}
+
+const defaultTrimRatio = 0.1
+
+func defaultPathWeights() []PathWeight {
+	return []PathWeight{
+		{Pattern: `test`, Weight: 0.1},
+		{Pattern: `^cmd/|/internal/`, Weight: 0.2},
+	}
+}
+
+// validate fills defaults, checks names and compiles the path patterns.
+// Dimension weights are checked by useRubric once the pack is loaded.
+func (sc *Scoring) validate() error {
+	if sc.Paths == nil {
+		sc.Paths = defaultPathWeights()
+	}
+	for i := range sc.Paths {
+		re, err := regexp.Compile(sc.Paths[i].Pattern)
+		if err != nil {
+			return fmt.Errorf("scoring: path pattern %q: %w", sc.Paths[i].Pattern, err)
+		}
+		sc.Paths[i].re = re
+	}
+
+	if sc.Aggregation == "" {
+		sc.Aggregation = aggregateMean
+	}
+	if !slices.Contains(aggregations, sc.Aggregation) {
+		return fmt.Errorf("scoring: unknown aggregation %q, want one of %v", sc.Aggregation, aggregations)
+	}
+	if sc.TrimRatio < 0 || sc.TrimRatio >= 0.5 {
+		return fmt.Errorf("scoring: trim_ratio must be in [0, 0.5), got %v", sc.TrimRatio)
+	}
+
+	if sc.MinChunks <= 0 {
+		sc.MinChunks = 8
+	}
+	if sc.MaxInterval <= 0 {
+		sc.MaxInterval = 20
+	}
+
+	return nil
+}
+
+// useRubric sets the rubric pack and checks the dimension weights against it.
+func (sc *Scoring) useRubric(r *Rubric) error {
+	keys := r.Keys()
+	for d, w := range sc.Dimensions {
+		if !slices.Contains(keys, d) {
+			return fmt.Errorf("scoring: unknown dimension %q for rubric %q, want one of %v", d, r.Name, keys)
+		}
+		if w < 0 {
+			return fmt.Errorf("scoring: dimension %q has negative weight %v", d, w)
+		}
+	}
+
+	sc.rubric = r
+	var total float64
+	for _, d := range keys {
+		total += sc.weight(d)
+	}
+	if total == 0 {
+		return fmt.Errorf("scoring: dimension weights add up to zero")
+	}
+	return nil
+}
+
+func (sc *Scoring) weight(dim string) float64 {
+	if w, ok := sc.Dimensions[dim]; ok {
+		return w
+	}
+	return 1
+}
+
+// dimensionValues picks the rubric dimensions out of a chunk score,
+// clamped to the rubric scale. Dimensions the LLM skipped count as 0.
+func (sc *Scoring) dimensionValues(c ChunkScore) map[string]float64 {
+	out := make(map[string]float64, len(sc.rubric.Dimensions))
+	for _, d := range sc.rubric.Keys() {
+		out[d] = math.Max(0, math.Min(c.Scores[d], float64(sc.rubric.Scale)))
+	}
+	return out
+}
+
+// chunkValue is the weighted mean of the dimensions, normalized to 0..1.
+// ok is false when the LLM returned no scores for the chunk.
+func (sc *Scoring) chunkValue(c ChunkScore) (v float64, ok bool) {
+	var sum, weights float64
+	for d, val := range sc.dimensionValues(c) {
+		if val != 0 {
+			ok = true
+		}
+		w := sc.weight(d)
+		sum += w * val
+		weights += w
+	}
+	return sum / (float64(sc.rubric.Scale) * weights), ok
+}
+
+// pathWeight is 1 plus the weight of every pattern the path matches.
+func (sc *Scoring) pathWeight(p string) float64 {
+	w := 1.0
+	p = strings.ToLower(p)
+	for _, pw := range sc.Paths {
+		if pw.re.MatchString(p) {
+			w += pw.Weight
+		}
+	}
+	return w
+}
+
+type weightedValue struct {
+	v, w float64
+}
+
+// aggregate combines weighted chunk values into a 0..1 repo value. It sorts
+// a copy, so callers keep vals in chunk order.
+func (sc *Scoring) aggregate(vals []weightedValue) float64 {
+	if len(vals) == 0 {
+		return 0
+	}
+	vals = slices.Clone(vals)
+	sort.Slice(vals, func(i, j int) bool { return vals[i].v < vals[j].v })
+
+	switch sc.Aggregation {
+	case aggregateTrimmedMean:
+		k := int(math.Floor(float64(len(vals)) * sc.TrimRatio))
+		return weightedMean(vals[k : len(vals)-k])
+	case aggregateMedian:
+		var total float64
+		for _, x := range vals {
+			total += x.w
+		}
+		var acc float64
+		for _, x := range vals {
+			acc += x.w
+			if acc >= total/2 {
+				return x.v
+			}
+		}
+		return vals[len(vals)-1].v
+	}
+	return weightedMean(vals)
+}
+
+const bootstrapRounds = 1000
+
+// bootstrap resamples the chunk values with replacement and returns the
+// 2.5th and 97.5th percentiles of the aggregate, on the 0-100 scale.
+func (sc *Scoring) bootstrap(vals []weightedValue, rng *rand.Rand) (low, high int) {
+	if len(vals) == 0 {
+		return 0, 0
+	}
+	stats := make([]float64, bootstrapRounds)
+	sample := make([]weightedValue, len(vals))
+	for i := range stats {
+		for j := range sample {
+			sample[j] = vals[rng.Intn(len(vals))]
+		}
+		stats[i] = sc.aggregate(sample)
+	}
+	sort.Float64s(stats)
+
+	at := func(q float64) int {
+		return clamp(int(stats[int(q*float64(len(stats)-1))]*100), 0, 100)
+	}
+	return at(0.025), at(0.975)
+}
+
+// confidence builds the interval and coverage for a repo and decides
+// whether its score is too thin to trust. blend maps an aggregated chunk
+// score to the reported one, so the interval brackets the repo score.
+func (sc *Scoring) confidence(vals []weightedValue, blend func(int) int, sampledFiles, sourceFiles int, seed int64) Confidence {
+	c := Confidence{SampledFiles: sampledFiles, SourceFiles: sourceFiles}
+	c.Low, c.High = sc.bootstrap(vals, rand.New(rand.NewSource(seed)))
+	c.Low, c.High = blend(c.Low), blend(c.High)
+	if sourceFiles > 0 {
+		c.Coverage = float64(sampledFiles) / float64(sourceFiles)
+	}
+
+	switch {
+	case len(vals) < sc.MinChunks:
+		c.LowConfidence = true
+		c.Reason = fmt.Sprintf("only %d scored chunks", len(vals))
+	case c.High-c.Low > sc.MaxInterval:
+		c.LowConfidence = true
+		c.Reason = fmt.Sprintf("95%% interval spans %d points", c.High-c.Low)
+	}
+	return c
+}
+
+func weightedMean(vals []weightedValue) float64 {
+	var sum, weights float64
+	for _, x := range vals {
+		sum += x.v * x.w
+		weights += x.w
+	}
+	if weights == 0 {
+		return 0
+	}
+	return sum / weights
+}
+
+// profileDimensions averages the dimensions of every scored repo.
+func profileDimensions(results []RepoResult) map[string]float64 {
+	out := map[string]float64{}
+	n := 0
+	for _, r := range results {
+		if len(r.Dimensions) == 0 {
+			continue
+		}
+		for d, v := range r.Dimensions {
+			out[d] += v
+		}
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	for d := range out {
+		out[d] /= float64(n)
+	}
+	return out
+}
+
+// Formula describes the scoring model in plain text for the report.
+func (sc *Scoring) Formula(static Static) string {
+	var dims []string
+	var weights float64
+	for _, d := range sc.rubric.Keys() {
+		if w := sc.weight(d); w > 0 {
+			dims = append(dims, fmt.Sprintf("%g·%s", w, d))
+			weights += w
+		}
+	}
+	f := fmt.Sprintf("chunk = (%s) / %g", strings.Join(dims, " + "), float64(sc.rubric.Scale)*weights)
+
+	w := "1"
+	for _, pw := range sc.Paths {
+		w += fmt.Sprintf(" + %g if path ~ %q", pw.Weight, pw.Pattern)
+	}
+	f += "; weight = " + w
+
+	switch sc.Aggregation {
+	case aggregateTrimmedMean:
+		f += fmt.Sprintf("; repo = 100 × weighted mean, dropping %g%% at each end", sc.TrimRatio*100)
+	case aggregateMedian:
+		f += "; repo = 100 × weighted median"
+	default:
+		f += "; repo = 100 × weighted mean"
+	}
+
+	if static.Enabled && static.Weight > 0 {
+		f += fmt.Sprintf("; final = %g × repo + %g × static (Go only)", 1-static.Weight, static.Weight)
+		if len(static.Factors) > 0 {
+			var parts []string
+			for _, name := range staticFactors {
+				parts = append(parts, fmt.Sprintf("%s %g", name, static.factorWeight(name)))
+			}
+			f += "; static factors: " + strings.Join(parts, ", ")
+		}
+	}
+	return f
+}
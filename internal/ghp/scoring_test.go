@@ -0,0 +1,30 @@
+package ghp
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	vals := []weightedValue{{1, 1}, {0.3, 1}, {0.1, 1}, {0.4, 1}, {0.2, 1}}
+	tests := []struct {
+		aggregation string
+		trim        float64
+		want        float64
+	}{
+		{aggregateMean, 0, 0.4},
+		{aggregateTrimmedMean, 0.2, 0.3},
+		{aggregateTrimmedMean, 0, 0.4},
+		{aggregateMedian, 0, 0.3},
+	}
+	for _, tt := range tests {
+		sc := &Scoring{Aggregation: tt.aggregation, TrimRatio: tt.trim}
+		before := slices.Clone(vals)
+		if got := sc.aggregate(vals); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("%s(trim %v) = %v, want %v", tt.aggregation, tt.trim, got, tt.want)
+		}
+		if !slices.Equal(vals, before) {
+			t.Errorf("%s reordered its input: %v", tt.aggregation, vals)
+		}
+	}
+}
@@ -9,63 +9,154 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"time"
 )
 
 type Service interface {
 	Submit(ctx context.Context, user string) (html string, err error)
+	Calibrate(ctx context.Context, set *BenchmarkSet) (*Calibration, error)
+}
+
+// languageOverlays maps FileChunk.Language to its overlay under
+// prompts/lang. Other languages get the generic review prompt.
+var languageOverlays = map[string]string{
+	"Go":         "go",
+	"TypeScript": "typescript",
+	"Python":     "python",
+	"Rust":       "rust",
+	"Java":       "java",
 }
 
 type service struct {
-	cfg                *Config
-	llm                Client
-	repoPrompt         string
-	summaryPrompt      string
-	headlinePrompt     string
-	standardArchPrompt string
-	monoRepoArchPrompt string
-	gh                 ghRepo
+	cfg            *Config
+	llm            Client
+	repoPrompt     *Prompt
+	langGuides     map[string]*Prompt
+	summaryPrompt  *Prompt
+	headlinePrompt *Prompt
+	archPrompts    map[RepoType]*Prompt
+	commitPrompt   *Prompt
+	testingPrompt  *Prompt
+	notesPrompt    *Prompt
+	docsPrompt     *Prompt
+	prompts        []*Prompt
+	gh             ghRepo
+	tok            tokenEstimator
+	advisories     *advisoryDB
+	calibration    *Calibration
+	std            *stdImporter
 }
 
 func NewService(cfg *Config, client Client, fsys embed.FS) (Service, error) {
-	repoPrompt, _, err := loadPrompt(fsys, cfg.App.PromptPath, "prompts/prompt.txt")
+	rubric, err := loadRubric(fsys, cfg.Scoring.Rubric)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Scoring.useRubric(rubric); err != nil {
+		return nil, err
+	}
+
+	prompts := &promptLoader{fsys: fsys, dir: cfg.App.PromptsDir}
+
+	repoPrompt, err := prompts.load("prompts/prompt.txt", cfg.App.PromptPath, ChunkPromptData{})
 	if err != nil {
 		return nil, fmt.Errorf("repo prompt: %w", err)
 	}
 
-	summaryPrompt, _, err := loadPrompt(fsys, "", "prompts/summary.txt")
+	langGuides := map[string]*Prompt{}
+	for lang, name := range languageOverlays {
+		langGuides[lang], err = prompts.load("prompts/lang/"+name+".txt", "", ChunkPromptData{})
+		if err != nil {
+			return nil, fmt.Errorf("%s prompt overlay: %w", lang, err)
+		}
+	}
+
+	summaryPrompt, err := prompts.load("prompts/summary.txt", "", SummaryPromptData{})
 	if err != nil {
 		return nil, fmt.Errorf("summary prompt: %w", err)
 	}
 
-	headlinePrompt, _, err := loadPrompt(fsys, "", "prompts/headline_summary.txt")
+	headlinePrompt, err := prompts.load("prompts/headline_summary.txt", "", SummaryPromptData{})
 	if err != nil {
 		return nil, fmt.Errorf("headline prompt: %w", err)
 	}
 
-	standardArchPrompt, _, err := loadPrompt(fsys, "", "prompts/arch_standard.txt")
+	archTypes := []RepoType{RepoStandard}
+	for _, r := range repoTypes {
+		archTypes = append(archTypes, r.Type)
+	}
+	archPrompts := map[RepoType]*Prompt{}
+	for _, t := range archTypes {
+		archPrompts[t], err = prompts.load(t.archPrompt(), "", ArchPromptData{})
+		if err != nil {
+			return nil, fmt.Errorf("%s arch prompt: %w", t, err)
+		}
+	}
+
+	commitPrompt, err := prompts.load("prompts/commit_messages.txt", "", CommitPromptData{})
 	if err != nil {
-		return nil, fmt.Errorf("standard arch prompt: %w", err)
+		return nil, fmt.Errorf("commit prompt: %w", err)
+	}
+
+	testingPrompt, err := prompts.load("prompts/testing.txt", "", ChunkPromptData{})
+	if err != nil {
+		return nil, fmt.Errorf("testing prompt: %w", err)
+	}
+
+	notesPrompt, err := prompts.load("prompts/consolidate_notes.txt", "", ChunkPromptData{})
+	if err != nil {
+		return nil, fmt.Errorf("notes prompt: %w", err)
+	}
+
+	docsPrompt, err := prompts.load("prompts/docs.txt", "", ChunkPromptData{})
+	if err != nil {
+		return nil, fmt.Errorf("docs prompt: %w", err)
+	}
+
+	var advisories *advisoryDB
+	if cfg.Deps.Enabled && cfg.Deps.AdvisoryDB != "" {
+		advisories, err = loadAdvisoryDB(cfg.Deps.AdvisoryDB)
+		if err != nil {
+			return nil, fmt.Errorf("advisory db: %w", err)
+		}
 	}
 
-	monoRepoArchPrompt, _, err := loadPrompt(fsys, "", "prompts/arch_monorepo.txt")
+	var calibration *Calibration
+	if cfg.App.Calibration != "" {
+		calibration, err = LoadCalibration(cfg.App.Calibration)
+		if err != nil {
+			return nil, fmt.Errorf("calibration: %w", err)
+		}
+	}
+
+	tokenSrc, err := newTokenSource(cfg.Auth)
 	if err != nil {
-		return nil, fmt.Errorf("monorepo arch prompt: %w", err)
+		return nil, fmt.Errorf("auth: %w", err)
 	}
 
-	gr, err := newGitHubRepo(cfg.Auth.GithubToken)
+	gr, err := newGitHubRepo(tokenSrc, cfg.App.GithubRetries, cfg.Auth.CacheKey)
 	if err != nil {
 		return nil, err
 	}
 
 	return &service{
-		cfg:                cfg,
-		llm:                client,
-		repoPrompt:         repoPrompt,
-		summaryPrompt:      summaryPrompt,
-		headlinePrompt:     headlinePrompt,
-		standardArchPrompt: standardArchPrompt,
-		monoRepoArchPrompt: monoRepoArchPrompt,
-		gh:                 gr,
+		cfg:            cfg,
+		llm:            client,
+		repoPrompt:     repoPrompt,
+		langGuides:     langGuides,
+		summaryPrompt:  summaryPrompt,
+		headlinePrompt: headlinePrompt,
+		archPrompts:    archPrompts,
+		commitPrompt:   commitPrompt,
+		testingPrompt:  testingPrompt,
+		notesPrompt:    notesPrompt,
+		docsPrompt:     docsPrompt,
+		prompts:        prompts.loaded,
+		gh:             gr,
+		advisories:     advisories,
+		calibration:    calibration,
+		tok:            newTokenEstimator(cfg.LLM.Provider, cfg.LLM.Model),
+		std:            newStdImporter(),
 	}, nil
 }
 
@@ -76,6 +167,8 @@ func (s *service) Submit(ctx context.Context, user string) (string, error) {
 		IncludePinned:    s.cfg.App.IncludePinned,
 		IncludeNonPinned: s.cfg.App.IncludeNonPinned,
 		ExcludeForks:     s.cfg.App.ExcludeForks,
+		IncludePrivate:   s.cfg.App.IncludePrivate,
+		PrivateAllowlist: s.cfg.App.PrivateAllowlist,
 	})
 	if err != nil {
 		return "", err
@@ -86,6 +179,11 @@ func (s *service) Submit(ctx context.Context, user string) (string, error) {
 
 	fmt.Printf("%d repositories found. Analyzing...\n", len(repos))
 
+	meta := ReportMeta{Sampling: s.cfg.App.Sampling, Seed: s.cfg.App.SamplingSeed, Formula: s.cfg.Scoring.Formula(s.cfg.Static), Rubric: *s.cfg.Scoring.rubric, Prompts: s.prompts}
+	if meta.Seed == 0 {
+		meta.Seed = time.Now().UnixNano()
+	}
+
 	results := make([]RepoResult, len(repos))
 	wg := sync.WaitGroup{}
 	sem := make(chan struct{}, s.cfg.LLM.ParallelRequests)
@@ -98,7 +196,7 @@ func (s *service) Submit(ctx context.Context, user string) (string, error) {
 			sem <- struct{}{}
 			defer func() { <-sem }()
 			fmt.Printf("Analyzing repo: %s/%s...\n", repos[i].Owner, repos[i].Name)
-			res, _ := s.evaluateRepo(ctx, repos[i])
+			res, _ := s.evaluateRepo(ctx, repos[i], repoSeed(meta.Seed, repos[i]))
 			results[i] = res
 			fmt.Printf("Repo %s/%s analyzed.\n", repos[i].Owner, repos[i].Name)
 		}()
@@ -109,9 +207,13 @@ func (s *service) Submit(ctx context.Context, user string) (string, error) {
 
 	slices.SortFunc(results, func(a, b RepoResult) int { return b.Score - a.Score })
 
+	if c := s.calibration; c != nil {
+		s.applyCalibration(c, results, &meta)
+	}
+
 	headlineHTML := s.generateHeadlineWithLLM(ctx, user, results)
 	summaryHTML := s.generateSummaryWithLLM(ctx, user, results)
-	return renderHTML(user, results, headlineHTML, summaryHTML), nil
+	return renderHTML(user, results, headlineHTML, summaryHTML, meta), nil
 }
 
 func (s *service) generateHeadlineWithLLM(ctx context.Context, user string, results []RepoResult) string {
@@ -120,7 +222,7 @@ func (s *service) generateHeadlineWithLLM(ctx context.Context, user string, resu
 	}
 	var b strings.Builder
 	b.WriteString("Repository Analysis Table:\n")
-	b.WriteString("Repo\tScore\tStrengths\tRisks\n")
+	b.WriteString("Repo\tScore\tStrengths\tRisks\tCommits/wk\tConventional%\tMsg quality\tAvg change\tRecent (90d)\tDocs\n")
 	for _, r := range results {
 		strengths := []string{}
 		for _, s := range r.ArchStrengths {
@@ -130,23 +232,25 @@ func (s *service) generateHeadlineWithLLM(ctx context.Context, user string, resu
 		for _, c := range r.ArchConsiderations {
 			risks = append(risks, c.Point)
 		}
-		b.WriteString(fmt.Sprintf("%s/%s\t%d\t%s\t%s\n",
+		h := r.History
+		b.WriteString(fmt.Sprintf("%s/%s\t%d\t%s\t%s\t%.1f\t%.0f\t%s\t%.0f\t%d\t%s\n",
 			r.Repo.Owner, r.Repo.Name, r.Score,
 			strings.Join(strengths, ", "),
-			strings.Join(risks, ", ")))
+			strings.Join(risks, ", "),
+			h.CommitsPerWeek, h.ConventionalRatio*100, h.messageQuality(), h.AvgChangeSize, h.RecentCommits, r.Docs.score()))
 	}
 
-	prompt := strings.NewReplacer("{{.SummaryData}}", b.String()).Replace(s.headlinePrompt)
+	data := SummaryPromptData{User: user, SummaryData: b.String()}
 
 	type headlineOut struct {
 		Headline string `json:"headline"`
 	}
 	var out headlineOut
 	err := s.llm.EvaluateJSON(ctx, EvalInput{
-		Prompt: prompt,
+		Prompt: s.headlinePrompt,
+		Data:   func(Chunk) any { return data },
 		Owner:  user,
 		Repo:   "(all)",
-		Chunks: []Chunk{{Path: "summary", Content: b.String(), Language: "text"}},
 	}, &out)
 
 	if err != nil || out.Headline == "" {
@@ -165,22 +269,21 @@ func (s *service) generateSummaryWithLLM(ctx context.Context, user string, resul
 	for _, r := range results {
 		b.WriteString(fmt.Sprintf("%s/%s\t%d\t%s\t%s\n",
 			r.Repo.Owner, r.Repo.Name, r.Score,
-			strings.Join(r.Strengths, ", "),
-			strings.Join(r.Risks, ", ")))
+			strings.Join(noteTexts(r.Strengths), ", "),
+			strings.Join(noteTexts(r.Risks), ", ")))
 	}
 
-	prompt := strings.NewReplacer("{{.SummaryData}}", b.String()).Replace(s.summaryPrompt)
+	data := SummaryPromptData{User: user, SummaryData: b.String()}
 
 	type summaryOut struct {
 		Summary string `json:"summary"`
 	}
 	var out summaryOut
 	err := s.llm.EvaluateJSON(ctx, EvalInput{
-		Prompt: prompt,
+		Prompt: s.summaryPrompt,
+		Data:   func(Chunk) any { return data },
 		Owner:  user,
 		Repo:   "(all)",
-		Branch: "",
-		Chunks: []Chunk{{Path: "summary", Content: b.String(), Language: "text"}},
 	}, &out)
 	if err != nil || out.Summary == "" {
 		return `<section class="mt-8 p-4 bg-yellow-50 border-l-4 border-yellow-400"><strong>AI Summary:</strong> <em>Summary unavailable.</em></section>`
@@ -188,97 +291,183 @@ func (s *service) generateSummaryWithLLM(ctx context.Context, user string, resul
 	return fmt.Sprintf(`<section class="mt-8 p-4 bg-yellow-50 border-l-4 border-yellow-400"><strong>AI Summary:</strong> %s</section>`, html.EscapeString(out.Summary))
 }
 
-func (s *service) evaluateRepo(ctx context.Context, repo RepoTarget) (RepoResult, error) {
+// reviewData builds the review prompt data for a chunk, adding the rubric
+// and the overlay for the chunk's language, if any.
+func (s *service) reviewData(in EvalInput) func(Chunk) any {
+	return func(ch Chunk) any {
+		d := in.chunkData(ch)
+		d.Rubric = s.cfg.Scoring.rubric.PromptSection()
+		d.Scores = s.cfg.Scoring.rubric.OutputFormat()
+		if guide, ok := s.langGuides[ch.Language]; ok {
+			text, err := guide.Render(d)
+			if err != nil {
+				fmt.Printf("warn: %s prompt overlay: %v\n", ch.Language, err)
+			}
+			d.LanguageGuide = strings.TrimSpace(text)
+		}
+		return d
+	}
+}
+
+func (s *service) evaluateRepo(ctx context.Context, repo RepoTarget, seed int64) (RepoResult, error) {
 	sha, err := s.gh.GetLatestCommitSHA(ctx, repo.Owner, repo.Name, repo.DefaultBranch)
+	if err == nil && len(sha) < 7 {
+		err = fmt.Errorf("unexpected commit SHA %q", sha)
+	}
 	if err != nil {
 		fmt.Printf("warn: could not get commit SHA for %s/%s: %v\n", repo.Owner, repo.Name, err)
+		return RepoResult{Repo: repo}, fmt.Errorf("commit SHA of %s: %w", repo.DefaultBranch, err)
 	}
 
 	fmt.Printf("Fetching file tree for %s/%s (sha: %s)...\n", repo.Owner, repo.Name, sha[:7])
-	tree, err := s.gh.ListTree(ctx, repo.Owner, repo.Name, repo.DefaultBranch, sha)
+	entries, err := s.gh.ListTree(ctx, repo.Owner, repo.Name, repo.DefaultBranch, sha)
 	if err != nil {
 		return RepoResult{Repo: repo}, err
 	}
+	tree := treePaths(entries)
+	entries, excluded := s.excludeNonAuthored(ctx, repo, sha, entries)
+
+	// Repository health checklist
+	health := s.evaluateHealth(ctx, repo, sha, tree)
 
-	// Architectural Analysis
-	archResult := s.evaluateArchitecture(ctx, repo, tree)
+	// Architectural Analysis, with a prompt for the repository type
+	// NOTE: Classify on authored files, vendored handlers or mains must not
+	// decide the type.
+	class := classifyRepo(treePaths(entries))
+	archResult := s.evaluateArchitecture(ctx, repo, class, tree, health)
 
-	paths := pickPaths(tree, s.cfg)
+	// Commit history and engineering hygiene
+	history, historyRedactions := s.evaluateHistory(ctx, repo, sha)
+
+	// Test suite across the whole tree
+	testSuite, testRedactions := s.evaluateTesting(ctx, repo, sha, entries)
+
+	// README and top-level docs
+	docs, docsRedactions := s.evaluateDocs(ctx, repo, sha, entries)
+	redactions := slices.Concat(historyRedactions, testRedactions, docsRedactions)
+	sortRedactions(redactions)
+
+	// Dependency manifests and advisories
+	var deps DependencyReport
+	if s.cfg.Deps.Enabled {
+		deps = s.evaluateDependencies(ctx, repo, sha, entries)
+	}
+
+	in := samplingInput{Entries: entries, Seed: seed}
+	if s.cfg.App.Sampling == samplingRecency {
+		in.Recent = s.recentPaths(ctx, repo, sha)
+	}
+	paths := pickPaths(in, s.cfg)
 	fmt.Printf("%d files selected for %s/%s\n", len(paths), repo.Owner, repo.Name)
-	chunks, _ := s.sampleChunks(ctx, repo, paths, sha)
+	chunks, skipped, _ := s.sampleChunks(ctx, repo, paths, sha)
+	excluded = append(excluded, skipped...)
+
+	// Deterministic static analysis
+	var static StaticMetrics
+	if s.cfg.Static.Enabled {
+		static = s.analyzeGo(ctx, repo, sha, paths)
+	}
+
 	if len(chunks) == 0 {
 		fmt.Printf("No chunks for %s/%s\n", repo.Owner, repo.Name)
-		return RepoResult{Repo: repo}, nil
+		return RepoResult{Repo: repo, SHA: sha, Class: class, History: history, Testing: testSuite, Docs: docs, Health: health, Deps: deps, Excluded: excluded, Redactions: redactions, Static: static,
+			Confidence: Confidence{SourceFiles: testSuite.SourceFiles + testSuite.TestFiles, LowConfidence: true, Reason: "no code sampled"}}, nil
 	}
 
-	in := EvalInput{
+	// NOTE: Redact before grouping, grouped chunks no longer map lines 1:1.
+	chunks, codeRedactions := redactChunks(chunks)
+	// A test or doc file can be sampled as code too.
+	redactions = append(redactions, codeRedactions...)
+	sortRedactions(redactions)
+	redactions = slices.Compact(redactions)
+	if len(redactions) > 0 {
+		fmt.Printf("%d secrets or PII redacted in %s/%s\n", len(redactions), repo.Owner, repo.Name)
+	}
+	chunks = groupChunks(chunks, s.tok, s.cfg.LLM.GroupTokens)
+
+	evalIn := EvalInput{
 		Prompt: s.repoPrompt, Owner: repo.Owner, Repo: repo.Name, Branch: repo.DefaultBranch,
 		Chunks: toLLMChunks(chunks),
 	}
+	evalIn.Data = s.reviewData(evalIn)
 	var scores []ChunkScore
-	err = s.llm.EvaluateJSON(ctx, in, &scores)
+	err = s.llm.EvaluateJSON(ctx, evalIn, &scores)
 	if err != nil {
 		fmt.Printf("LLM error in %s/%s: %v\n", repo.Owner, repo.Name, err)
 	}
 
-	var sum float64
-	var cnt float64
-	var strengths, risks []string
+	var values []weightedValue
+	dimValues := map[string][]weightedValue{}
+	var notes []Note
 	var samples []struct{ URL, Note string }
 
 	for i, sc := range scores {
-		total := sc.Readability + sc.Design + sc.Testing + sc.Maintain + sc.Idiomatic + sc.Security
-		if total == 0 {
+		v, ok := s.cfg.Scoring.chunkValue(sc)
+		if !ok {
 			continue
 		}
-		w := 1.0
-		p := strings.ToLower(chunks[i].Path)
-		if strings.Contains(p, "test") {
-			w += 0.1
-		}
-		if strings.HasPrefix(p, "cmd/") || strings.Contains(p, "/internal/") {
-			w += 0.2
+		w := s.cfg.Scoring.pathWeight(chunks[i].Path)
+		values = append(values, weightedValue{v: v, w: w})
+		for d, val := range s.cfg.Scoring.dimensionValues(sc) {
+			dimValues[d] = append(dimValues[d], weightedValue{v: val, w: w})
 		}
-		sum += (float64(total) / 30.0) * w
-		cnt += w
 
 		if len(samples) < 3 && len(sc.Citations) > 0 {
 			samples = append(samples, struct{ URL, Note string }{
-				URL:  fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s", repo.Owner, repo.Name, repo.DefaultBranch, chunks[i].Path),
+				URL:  fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s#L%d-L%d", repo.Owner, repo.Name, sha, chunks[i].Path, chunks[i].StartLine, chunks[i].EndLine),
 				Note: first(sc.Notes),
 			})
 		}
-		for _, n := range sc.Notes {
-			ln := strings.ToLower(n)
-			switch {
-			case strings.Contains(ln, "well-structured"), strings.Contains(ln, "idiomatic"), strings.Contains(ln, "tested"):
-				if len(strengths) < 3 {
-					strengths = append(strengths, n)
-				}
-			case strings.Contains(ln, "missing tests"), strings.Contains(ln, "long function"),
-				strings.Contains(ln, "globals"), strings.Contains(ln, "security"), strings.Contains(ln, "concurrency"):
-				if len(risks) < 3 {
-					risks = append(risks, n)
-				}
-			}
-		}
+		notes = append(notes, sc.Notes...)
+	}
+
+	strengths, risks := s.consolidateNotes(ctx, repo, notes)
+
+	// The static score is deterministic, so blending it in is the same
+	// affine map for the score and its interval.
+	blend := func(v int) int { return v }
+	if w := s.cfg.Static.Weight; static.Analyzed && w > 0 {
+		blend = func(v int) int { return clamp(int((1-w)*float64(v)+w*float64(static.Score)), 0, 100) }
 	}
+	final := blend(clamp(int(s.cfg.Scoring.aggregate(values)*100.0), 0, 100))
 
-	final := 0
-	if cnt > 0 {
-		final = clamp(int((sum/cnt)*100.0), 0, 100)
+	sampled := map[string]bool{}
+	for _, c := range chunks {
+		if isSourcePath(c.Path) {
+			sampled[c.Path] = true
+		}
 	}
+	conf := s.cfg.Scoring.confidence(values, blend, len(sampled), testSuite.SourceFiles+testSuite.TestFiles, seed)
 
+	var dims map[string]float64
+	if len(values) > 0 {
+		dims = map[string]float64{}
+		for d, vals := range dimValues {
+			dims[d] = s.cfg.Scoring.aggregate(vals)
+		}
+	}
 	return RepoResult{
-		Repo:          repo,
-		Score:         final,
-		Strengths:     strengths,
-		Risks:         risks,
+		Repo:               repo,
+		SHA:                sha,
+		Class:              class,
+		Score:              final,
+		Dimensions:         dims,
+		Confidence:         conf,
+		Strengths:          strengths,
+		Risks:              risks,
 		ArchStrengths:      archResult.ArchStrengths,
 		ArchConsiderations: archResult.ArchConsiderations,
-		Samples:       samples,
-		Files:         len(paths),
-		Chunks:        len(chunks),
+		Samples:            samples,
+		History:            history,
+		Testing:            testSuite,
+		Docs:               docs,
+		Health:             health,
+		Deps:               deps,
+		Excluded:           excluded,
+		Redactions:         redactions,
+		Static:             static,
+		Files:              len(paths),
+		Chunks:             len(chunks),
 	}, nil
 }
 
@@ -287,27 +476,28 @@ type archScore struct {
 	ArchConsiderations []ArchConsideration `json:"arch_considerations"`
 }
 
-func (s *service) evaluateArchitecture(ctx context.Context, repo RepoTarget, tree []string) archScore {
-	repoType := detectRepoType(tree)
-	var prompt string
-	if repoType == "monorepo" {
-		prompt = s.monoRepoArchPrompt
-	} else {
-		prompt = s.standardArchPrompt
+func (s *service) evaluateArchitecture(ctx context.Context, repo RepoTarget, class RepoClass, tree []string, health RepoHealth) archScore {
+	prompt, ok := s.archPrompts[class.Type]
+	if !ok {
+		prompt = s.archPrompts[RepoStandard]
 	}
 
-	r := strings.NewReplacer(
-		"{{.Language}}", repo.Language,
-		"{{.Tree}}", strings.Join(tree, "\n"),
-	)
-	finalPrompt := r.Replace(prompt)
+	data := ArchPromptData{
+		Owner:    repo.Owner,
+		Repo:     repo.Name,
+		Language: repo.Language,
+		Type:     class.Type.Label(),
+		Reason:   class.Reason,
+		Tree:     strings.Join(tree, "\n"),
+		Health:   health.String(),
+	}
 
 	var result archScore
 	err := s.llm.EvaluateJSON(ctx, EvalInput{
-		Prompt: finalPrompt,
+		Prompt: prompt,
+		Data:   func(Chunk) any { return data },
 		Owner:  repo.Owner,
 		Repo:   repo.Name,
-		Chunks: []Chunk{{Content: finalPrompt}},
 	}, &result)
 
 	if err != nil {
@@ -316,66 +506,38 @@ func (s *service) evaluateArchitecture(ctx context.Context, repo RepoTarget, tre
 	return result
 }
 
-func detectRepoType(paths []string) string {
-	hasAppsDir := false
-	hasPackagesDir := false
-	goModCount := 0
-
-	for _, path := range paths {
-		p := strings.ToLower(path)
-		if strings.HasPrefix(p, "apps/") {
-			hasAppsDir = true
-		}
-
-		if strings.HasPrefix(p, "packages/") || strings.HasPrefix(p, "libs/") {
-			hasPackagesDir = true
-		}
-
-		if filepath.Base(p) == "go.mod" {
-			goModCount++
-		}
-
-		if filepath.Base(p) == "lerna.json" || filepath.Base(p) == "turbo.json" || filepath.Base(p) == "nx.json" {
-			return "monorepo"
+// excludeNonAuthored drops generated, vendored, minified and lock files from
+// the tree, by path and by the repo's .gitattributes linguist rules. Only
+// files scorePath would have considered are reported as excluded.
+func (s *service) excludeNonAuthored(ctx context.Context, repo RepoTarget, sha string, entries []TreeEntry) ([]TreeEntry, []ExcludedFile) {
+	var rules linguistRules
+	for _, e := range entries {
+		if e.Path == ".gitattributes" {
+			data, err := s.gh.ReadFile(ctx, repo.Owner, repo.Name, repo.DefaultBranch, e.Path, sha)
+			if err != nil {
+				fmt.Printf("warn: could not read .gitattributes for %s/%s: %v\n", repo.Owner, repo.Name, err)
+			}
+			rules = parseGitattributes(data)
+			break
 		}
 	}
 
-	if hasAppsDir && hasPackagesDir {
-		return "monorepo"
-	}
-	if goModCount > 2 { // Allow for a root and one sub-module without calling it a monorepo
-		return "monorepo"
-	}
-
-	return "standard"
-}
-
-func pickPaths(entries []string, cfg *Config) []string {
-	type scoredPath struct {
-		Path  string
-		Score int
-	}
-
-	var scoredPaths []scoredPath
-	for _, p := range entries {
-		score := scorePath(p)
-		if score > 0 {
-			scoredPaths = append(scoredPaths, scoredPath{Path: p, Score: score})
+	kept := make([]TreeEntry, 0, len(entries))
+	var excluded []ExcludedFile
+	for _, e := range entries {
+		reason := rules.match(e.Path)
+		if reason == "" {
+			reason = excludedByPath(e.Path)
 		}
-	}
-
-	slices.SortFunc(scoredPaths, func(a, b scoredPath) int {
-		return b.Score - a.Score // Sort descending
-	})
-
-	var out []string
-	for _, sp := range scoredPaths {
-		out = append(out, sp.Path)
-		if len(out) >= cfg.App.ChunksPerRepo {
-			break
+		if reason == "" {
+			kept = append(kept, e)
+			continue
+		}
+		if scorePath(e.Path) > 0 {
+			excluded = append(excluded, ExcludedFile{Path: e.Path, Reason: reason})
 		}
 	}
-	return out
+	return kept, excluded
 }
 
 func scorePath(p string) int {
@@ -429,18 +591,20 @@ func scorePath(p string) int {
 		score -= 5
 	}
 
-	// Penalize lock files
-	if strings.HasSuffix(l, ".lock") || strings.HasSuffix(l, "go.sum") {
+	// Penalize lock files and manifests, the dependency stage parses those
+	if strings.HasSuffix(l, ".lock") || strings.HasSuffix(l, "go.sum") || isManifest(l) {
 		return 1
 	}
 
 	return score
 }
 
-func (s *service) sampleChunks(ctx context.Context, repo RepoTarget, files []string, sha string) ([]FileChunk, error) {
-	var chunks []FileChunk
+func (s *service) sampleChunks(ctx context.Context, repo RepoTarget, files []string, sha string) ([]FileChunk, []ExcludedFile, error) {
+	budget := s.cfg.LLM.TokenBudget
+	var chunks, units []FileChunk
+	var excluded []ExcludedFile
 	for _, fp := range files {
-		if len(chunks) >= s.cfg.App.ChunksPerRepo {
+		if budget <= 0 && len(chunks) >= s.cfg.App.ChunksPerRepo {
 			break
 		}
 
@@ -449,28 +613,38 @@ func (s *service) sampleChunks(ctx context.Context, repo RepoTarget, files []str
 			continue
 		}
 
-		if len(data) > s.cfg.App.MaxChunkBytes {
-			data = data[:s.cfg.App.MaxChunkBytes]
+		if reason := excludedByContent(data); reason != "" {
+			excluded = append(excluded, ExcludedFile{Path: fp, Reason: reason})
+			continue
 		}
 
-		lines := 1 + strings.Count(string(data), "\n")
-		chunks = append(chunks, FileChunk{
-			Path:      fp,
-			StartLine: 1,
-			EndLine:   lines,
-			Content:   string(data),
-			Language:  guessLang(fp),
-		})
+		// With a token budget every unit competes for it, see packChunks.
+		if budget > 0 {
+			units = append(units, chunkFile(fp, data)...)
+			continue
+		}
+
+		for _, c := range largestChunks(chunkFile(fp, data), s.cfg.App.ChunksPerFile) {
+			if len(chunks) >= s.cfg.App.ChunksPerRepo {
+				break
+			}
+			chunks = append(chunks, truncateChunk(c, s.cfg.App.MaxChunkBytes))
+		}
+	}
+
+	if budget > 0 {
+		chunks = packChunks(units, s.tok, budget, s.cfg.LLM.MaxChunkTokens)
+		fmt.Printf("%d of %d units packed for %s/%s\n", len(chunks), len(units), repo.Owner, repo.Name)
 	}
-	return chunks, nil
-}
 
+	return chunks, excluded, nil
+}
 
 func toLLMChunks(in []FileChunk) []Chunk {
 	out := make([]Chunk, len(in))
 	for i, c := range in {
 		out[i] = Chunk{
-			Path: c.Path, StartLine: c.StartLine, EndLine: c.EndLine, Content: c.Content, Language: c.Language,
+			Path: c.Path, StartLine: c.StartLine, EndLine: c.EndLine, Content: c.Content, Language: c.Language, Symbol: c.Symbol,
 		}
 	}
 
@@ -482,9 +656,9 @@ func guessLang(p string) string {
 	switch {
 	case strings.HasSuffix(l, ".go"):
 		return "Go"
-	case strings.HasSuffix(l, ".ts"):
+	case strings.HasSuffix(l, ".ts"), strings.HasSuffix(l, ".tsx"), strings.HasSuffix(l, ".mts"), strings.HasSuffix(l, ".cts"):
 		return "TypeScript"
-	case strings.HasSuffix(l, ".js"):
+	case strings.HasSuffix(l, ".js"), strings.HasSuffix(l, ".jsx"), strings.HasSuffix(l, ".mjs"), strings.HasSuffix(l, ".cjs"):
 		return "JavaScript"
 	case strings.HasSuffix(l, ".py"):
 		return "Python"
@@ -535,14 +709,19 @@ func guessLang(p string) string {
 	}
 }
 
-func first(s []string) string {
-	if len(s) > 0 {
-		return s[0]
+func first(notes []Note) string {
+	if len(notes) > 0 {
+		return notes[0].Text
 	}
 
 	return ""
 }
 
+// roundDiv divides two non-negative ints, rounding half up.
+func roundDiv(sum, n int) int {
+	return (2*sum + n) / (2 * n)
+}
+
 func clamp(v, lo, hi int) int {
 	if v < lo {
 		return lo
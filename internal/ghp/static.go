@@ -0,0 +1,422 @@
+package ghp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"math"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const longFuncLines = 60
+
+// StaticMetrics are deterministic, LLM-free measurements over the Go files
+// fetched for a repository. Test files only count towards gofmt conformance.
+type StaticMetrics struct {
+	Files           int
+	Funcs           int
+	AvgComplexity   float64
+	MaxComplexity   int
+	AvgFuncLines    float64
+	LongFuncs       int
+	Exported        int
+	ExportedNoDoc   int
+	IgnoredErrors   int
+	AvgFanOut       float64 // Imports of other packages in the same module
+	GofmtFiles      int
+	GofmtConformant int
+	Score           int // 0-100, see staticScore
+	Analyzed        bool
+	// StdUnresolved counts standard library imports that could not be
+	// type-checked, so errors dropped from their calls are not counted.
+	StdUnresolved int
+}
+
+// stdImporter type-checks standard library packages from GOROOT source.
+// One is shared by all repositories in a run, so each package is only
+// checked once.
+type stdImporter struct {
+	mu  sync.Mutex
+	imp types.Importer
+}
+
+func newStdImporter() *stdImporter {
+	return &stdImporter{imp: importer.ForCompiler(token.NewFileSet(), "source", nil)}
+}
+
+func (si *stdImporter) Import(path string) (*types.Package, error) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	return si.imp.Import(path)
+}
+
+type goPackage struct {
+	dir   string
+	files []*ast.File
+	pkg   *types.Package
+	info  *types.Info
+	busy  bool
+}
+
+// analyzeGo parses and type-checks the fetched Go files package by package.
+// Packages of the same module are checked on demand so calls across them
+// resolve, the standard library is imported from source; anything else is
+// stubbed and its type errors are ignored.
+func (s *service) analyzeGo(ctx context.Context, repo RepoTarget, sha string, paths []string) StaticMetrics {
+	var m StaticMetrics
+	fset := token.NewFileSet()
+	pkgs := make(map[string]*goPackage)
+
+	for _, p := range paths {
+		if !strings.HasSuffix(p, ".go") {
+			continue
+		}
+		data, err := s.gh.ReadFile(ctx, repo.Owner, repo.Name, repo.DefaultBranch, p, sha)
+		if err != nil || len(data) == 0 || excludedByContent(data) != "" {
+			continue
+		}
+
+		m.GofmtFiles++
+		if formatted, err := format.Source(data); err == nil && bytes.Equal(formatted, data) {
+			m.GofmtConformant++
+		}
+
+		if strings.HasSuffix(p, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, p, data, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		m.Files++
+
+		dir := path.Dir(p)
+		if pkgs[dir] == nil {
+			pkgs[dir] = &goPackage{dir: dir}
+		}
+		pkgs[dir].files = append(pkgs[dir].files, f)
+	}
+	if m.GofmtFiles == 0 {
+		return m
+	}
+	m.Analyzed = true
+
+	module := ""
+	if data, err := s.gh.ReadFile(ctx, repo.Owner, repo.Name, repo.DefaultBranch, "go.mod", sha); err == nil {
+		module = modulePath(data)
+	}
+
+	byImport := make(map[string]*goPackage, len(pkgs))
+	for dir, p := range pkgs {
+		byImport[path.Join(module, dir)] = p
+	}
+
+	var check func(p *goPackage)
+	unresolved := make(map[string]bool)
+	imp := importerFunc(func(importPath string) (*types.Package, error) {
+		if dep, ok := byImport[importPath]; ok && !dep.busy {
+			check(dep)
+			if dep.pkg != nil {
+				return dep.pkg, nil
+			}
+		}
+		if isStdImport(importPath) {
+			pkg, err := s.std.Import(importPath)
+			if err == nil {
+				return pkg, nil
+			}
+			unresolved[importPath] = true
+		}
+		stub := types.NewPackage(importPath, path.Base(importPath))
+		stub.MarkComplete()
+		return stub, nil
+	})
+	check = func(p *goPackage) {
+		if p.pkg != nil || p.busy {
+			return
+		}
+		p.busy = true
+		p.info = &types.Info{Types: make(map[ast.Expr]types.TypeAndValue), Uses: make(map[*ast.Ident]types.Object)}
+		conf := types.Config{Importer: imp, Error: func(error) {}}
+		p.pkg, _ = conf.Check(path.Join(module, p.dir), fset, p.files, p.info)
+		p.busy = false
+	}
+
+	var complexity, lines, fanOut int
+	for importPath, p := range byImport {
+		check(p)
+		deps := make(map[string]bool)
+		for _, f := range p.files {
+			for _, is := range f.Imports {
+				ip := strings.Trim(is.Path.Value, `"`)
+				if module != "" && ip != importPath && strings.HasPrefix(ip, module+"/") {
+					deps[ip] = true
+				}
+			}
+			for _, decl := range f.Decls {
+				m.countDecl(decl)
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Body == nil {
+					continue
+				}
+				cx := cyclomatic(fd)
+				n := fset.Position(fd.End()).Line - fset.Position(fd.Pos()).Line + 1
+				m.Funcs++
+				complexity += cx
+				lines += n
+				m.MaxComplexity = max(m.MaxComplexity, cx)
+				if n > longFuncLines {
+					m.LongFuncs++
+				}
+				m.IgnoredErrors += ignoredErrors(fd.Body, p.info)
+			}
+		}
+		fanOut += len(deps)
+	}
+
+	if m.Funcs > 0 {
+		m.AvgComplexity = float64(complexity) / float64(m.Funcs)
+		m.AvgFuncLines = float64(lines) / float64(m.Funcs)
+	}
+	if len(byImport) > 0 {
+		m.AvgFanOut = float64(fanOut) / float64(len(byImport))
+	}
+	m.Score = staticScore(m, s.cfg.Static)
+
+	if m.StdUnresolved = len(unresolved); m.StdUnresolved > 0 {
+		fmt.Printf("warn: static analysis for %s/%s could not type-check %d standard library packages, ignored errors are under-counted (is GOROOT available?)\n",
+			repo.Owner, repo.Name, m.StdUnresolved)
+	}
+
+	return m
+}
+
+func (m *StaticMetrics) countDecl(decl ast.Decl) {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Name.IsExported() {
+			m.Exported++
+			if d.Doc == nil {
+				m.ExportedNoDoc++
+			}
+		}
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			var names []*ast.Ident
+			var doc *ast.CommentGroup
+			switch sp := spec.(type) {
+			case *ast.TypeSpec:
+				names, doc = []*ast.Ident{sp.Name}, sp.Doc
+			case *ast.ValueSpec:
+				names, doc = sp.Names, sp.Doc
+			}
+			if doc == nil {
+				doc = d.Doc
+			}
+			for _, n := range names {
+				if n.IsExported() {
+					m.Exported++
+					if doc == nil {
+						m.ExportedNoDoc++
+					}
+				}
+			}
+		}
+	}
+}
+
+// cyclomatic is McCabe's complexity: one plus every branch point.
+func cyclomatic(fd *ast.FuncDecl) int {
+	cx := 1
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			cx++
+		case *ast.CaseClause:
+			if x.List != nil {
+				cx++
+			}
+		case *ast.CommClause:
+			if x.Comm != nil {
+				cx++
+			}
+		case *ast.BinaryExpr:
+			if x.Op == token.LAND || x.Op == token.LOR {
+				cx++
+			}
+		}
+		return true
+	})
+	return cx
+}
+
+// ignoredErrors counts calls whose error result is dropped: assigned to the
+// blank identifier in last position or called as a statement. Only calls
+// type information shows to return an error count.
+func ignoredErrors(body *ast.BlockStmt, info *types.Info) int {
+	n := 0
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch x := node.(type) {
+		case *ast.AssignStmt:
+			if len(x.Rhs) != 1 {
+				return true
+			}
+			call, ok := x.Rhs[0].(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if id, ok := x.Lhs[len(x.Lhs)-1].(*ast.Ident); ok && id.Name == "_" && returnsError(call, info) {
+				n++
+			}
+		case *ast.ExprStmt:
+			if call, ok := x.X.(*ast.CallExpr); ok {
+				if returnsError(call, info) {
+					n++
+				}
+			}
+		}
+		return true
+	})
+	return n
+}
+
+// returnsError reports whether the type checker resolved the call and its
+// last result is an error.
+func returnsError(call *ast.CallExpr, info *types.Info) bool {
+	if info == nil || neverFails[callee(call, info)] {
+		return false
+	}
+	tv, ok := info.Types[call]
+	if !ok || tv.Type == nil || tv.Type == types.Typ[types.Invalid] {
+		return false
+	}
+	last := tv.Type
+	if tuple, ok := tv.Type.(*types.Tuple); ok {
+		if tuple.Len() == 0 {
+			return false
+		}
+		last = tuple.At(tuple.Len() - 1).Type()
+	}
+	return types.Identical(last, types.Universe.Lookup("error").Type())
+}
+
+// neverFails are callees whose error is always nil or conventionally
+// ignored, as in errcheck's default exclusions.
+var neverFails = map[string]bool{
+	"fmt.Print": true, "fmt.Printf": true, "fmt.Println": true,
+	"(*strings.Builder).Write": true, "(*strings.Builder).WriteString": true, "(*strings.Builder).WriteByte": true, "(*strings.Builder).WriteRune": true,
+	"(*bytes.Buffer).Write": true, "(*bytes.Buffer).WriteString": true, "(*bytes.Buffer).WriteByte": true, "(*bytes.Buffer).WriteRune": true,
+}
+
+func callee(call *ast.CallExpr, info *types.Info) string {
+	var id *ast.Ident
+	switch fn := call.Fun.(type) {
+	case *ast.Ident:
+		id = fn
+	case *ast.SelectorExpr:
+		id = fn.Sel
+	default:
+		return ""
+	}
+	if f, ok := info.Uses[id].(*types.Func); ok {
+		return f.FullName()
+	}
+	return ""
+}
+
+// staticFactors are the parts of the static score, in the order
+// staticScore computes them, by their static.factors key.
+var staticFactors = []string{"complexity", "func_length", "docs", "errors", "coupling", "gofmt"}
+
+func (st *Static) validate() error {
+	if st.Weight < 0 || st.Weight > 1 {
+		return fmt.Errorf("static.weight must be between 0 and 1, got %v", st.Weight)
+	}
+	var total float64
+	for f, w := range st.Factors {
+		if !slices.Contains(staticFactors, f) {
+			return fmt.Errorf("static: unknown factor %q, want one of %v", f, staticFactors)
+		}
+		if w < 0 {
+			return fmt.Errorf("static: factor %q has negative weight %v", f, w)
+		}
+	}
+	for _, f := range staticFactors {
+		total += st.factorWeight(f)
+	}
+	if total == 0 {
+		return fmt.Errorf("static: factor weights add up to zero")
+	}
+	return nil
+}
+
+func (st *Static) factorWeight(factor string) float64 {
+	if w, ok := st.Factors[factor]; ok {
+		return w
+	}
+	return 1
+}
+
+// staticScore folds the metrics into 0-100. Each factor is 0-1: complexity,
+// function length, docs on exported identifiers, handled errors, coupling
+// and gofmt conformance. The result is their weighted mean.
+func staticScore(m StaticMetrics, st Static) int {
+	unit := func(v float64) float64 { return math.Min(math.Max(v, 0), 1) }
+
+	factors := []float64{
+		unit(1 - (m.AvgComplexity-4)/11),
+		1,
+		1,
+		1,
+		unit(1 - (m.AvgFanOut-3)/7),
+		1,
+	}
+	if m.Funcs > 0 {
+		factors[1] = unit(1 - 2*float64(m.LongFuncs)/float64(m.Funcs))
+		factors[3] = unit(1 - 5*float64(m.IgnoredErrors)/float64(m.Funcs))
+	}
+	if m.Exported > 0 {
+		factors[2] = 1 - float64(m.ExportedNoDoc)/float64(m.Exported)
+	}
+	if m.GofmtFiles > 0 {
+		factors[5] = float64(m.GofmtConformant) / float64(m.GofmtFiles)
+	}
+
+	var sum, total float64
+	for i, f := range factors {
+		w := st.factorWeight(staticFactors[i])
+		sum += w * f
+		total += w
+	}
+	return int(sum / total * 100)
+}
+
+func modulePath(gomod []byte) string {
+	for _, line := range strings.Split(string(gomod), "\n") {
+		line = strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(line, "module"); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+// isStdImport tells standard library paths by their first element, which
+// has no dot.
+func isStdImport(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
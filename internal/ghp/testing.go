@@ -0,0 +1,292 @@
+package ghp
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	testFilesRead     = 5 // Test files read for framework and table-driven detection
+	testFilesReviewed = 2 // Test files sent to the LLM
+)
+
+// TestingMetrics is the repo-wide view of the test suite, built from the
+// full tree plus a handful of test files.
+type TestingMetrics struct {
+	SourceFiles      int
+	TestFiles        int
+	Ratio            float64
+	Packages         int
+	UntestedPackages []string
+	Frameworks       []string
+	TableDriven      int // Go test functions that range over a table of cases
+	ReviewScore      int // 0-5, LLM review of the largest test files
+	ReviewNotes      []string
+	ReviewedFiles    []string
+	Reviewed         bool // False when the review call failed
+}
+
+type testReview struct {
+	Score int      `json:"score"`
+	Notes []string `json:"notes"`
+}
+
+// frameworkMarkers maps files whose presence in the tree reveals a test
+// framework or runner.
+var frameworkMarkers = map[string]string{
+	"jest.config.js": "Jest", "jest.config.ts": "Jest", "vitest.config.ts": "Vitest", "vitest.config.js": "Vitest",
+	".mocharc.json": "Mocha", ".mocharc.yml": "Mocha", "karma.conf.js": "Karma",
+	"cypress.config.ts": "Cypress", "cypress.config.js": "Cypress", "playwright.config.ts": "Playwright",
+	"pytest.ini": "pytest", "conftest.py": "pytest", "tox.ini": "tox", ".rspec": "RSpec",
+}
+
+// importMarkers maps import paths found in test files to frameworks.
+var importMarkers = map[string]string{
+	`"testing"`: "go test", "github.com/stretchr/testify": "testify", "github.com/onsi/ginkgo": "Ginkgo",
+	"github.com/onsi/gomega": "Gomega", "github.com/google/go-cmp": "go-cmp", "gotest.tools": "gotest.tools",
+	"org.junit.jupiter": "JUnit 5", "org.junit": "JUnit", "org.mockito": "Mockito",
+	"import pytest": "pytest", "import unittest": "unittest", "from unittest": "unittest",
+	"@testing-library": "Testing Library", "from 'vitest'": "Vitest", `from "vitest"`: "Vitest",
+	"#[test]": "cargo test", "#[cfg(test)]": "cargo test",
+}
+
+func isTestPath(p string) bool {
+	l := strings.ToLower(p)
+	base := path.Base(l)
+	switch {
+	case strings.HasSuffix(l, "_test.go"), strings.Contains(base, ".test."), strings.Contains(base, ".spec."):
+		return true
+	case strings.HasSuffix(l, ".py") && (strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test.py")):
+		return true
+	case strings.HasSuffix(l, "_spec.rb"), strings.HasSuffix(l, "test.java"), strings.HasSuffix(l, "tests.cs"):
+		return true
+	case strings.Contains(l, "/__tests__/"), strings.HasPrefix(l, "__tests__/"), strings.Contains(l, "src/test/"):
+		return true
+	case strings.HasPrefix(l, "tests/") && strings.HasSuffix(l, ".rs"):
+		return true
+	}
+	return false
+}
+
+func isSourcePath(p string) bool {
+	switch guessLang(p) {
+	case "Go", "TypeScript", "JavaScript", "Python", "Ruby", "Java", "Rust", "C#", "C++", "Kotlin", "Swift", "PHP", "Dart", "Elixir":
+		return true
+	}
+	return false
+}
+
+// evaluateTesting measures the test suite across the whole tree, then reads
+// a few test files for framework and table-driven detection and has the
+// LLM review the largest ones. It also returns what was redacted from the
+// reviewed files.
+func (s *service) evaluateTesting(ctx context.Context, repo RepoTarget, sha string, entries []TreeEntry) (TestingMetrics, []Redaction) {
+	var m TestingMetrics
+	var found []Redaction
+	srcDirs := make(map[string]bool)
+	testDirs := make(map[string]bool)
+	var testBases []string
+	var tests []TreeEntry
+	frameworks := make(map[string]bool)
+
+	for _, e := range entries {
+		if fw, ok := frameworkMarkers[path.Base(e.Path)]; ok {
+			frameworks[fw] = true
+		}
+		if !isSourcePath(e.Path) {
+			continue
+		}
+		dir := path.Dir(e.Path)
+		if isTestPath(e.Path) {
+			m.TestFiles++
+			testDirs[dir] = true
+			testBases = append(testBases, strings.ToLower(path.Base(e.Path)))
+			tests = append(tests, e)
+			continue
+		}
+		m.SourceFiles++
+		srcDirs[dir] = true
+	}
+
+	m.Packages = len(srcDirs)
+	if m.SourceFiles > 0 {
+		m.Ratio = float64(m.TestFiles) / float64(m.SourceFiles)
+	}
+	for dir := range srcDirs {
+		if !packageTested(dir, testDirs, testBases) {
+			m.UntestedPackages = append(m.UntestedPackages, dir)
+		}
+	}
+	slices.Sort(m.UntestedPackages)
+
+	// Largest test files first, they say the most about the suite.
+	slices.SortFunc(tests, func(a, b TreeEntry) int { return b.Size - a.Size })
+
+	var review []Chunk
+	for i, t := range tests {
+		if i >= testFilesRead {
+			break
+		}
+		data, err := s.gh.ReadFile(ctx, repo.Owner, repo.Name, repo.DefaultBranch, t.Path, sha)
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		for marker, fw := range importMarkers {
+			if strings.Contains(string(data), marker) {
+				frameworks[fw] = true
+			}
+		}
+		if strings.HasSuffix(t.Path, ".go") {
+			m.TableDriven += tableDrivenTests(t.Path, data)
+		}
+		if len(review) < testFilesReviewed {
+			c := truncateChunkTokens(newChunk(t.Path, data, 1, ""), s.tok, max(s.cfg.LLM.MaxChunkTokens, 600))
+			redacted, r := redactChunks([]FileChunk{c})
+			found = append(found, r...)
+			review = append(review, toLLMChunks(redacted)...)
+			m.ReviewedFiles = append(m.ReviewedFiles, t.Path)
+		}
+	}
+
+	for fw := range frameworks {
+		m.Frameworks = append(m.Frameworks, fw)
+	}
+	slices.Sort(m.Frameworks)
+
+	if len(review) == 0 {
+		return m, found
+	}
+
+	var reviews []testReview
+	err := s.llm.EvaluateJSON(ctx, EvalInput{
+		Prompt: s.testingPrompt,
+		Owner:  repo.Owner,
+		Repo:   repo.Name,
+		Branch: repo.DefaultBranch,
+		Chunks: review,
+	}, &reviews)
+	if err != nil {
+		fmt.Printf("warn: test review failed for %s/%s: %v\n", repo.Owner, repo.Name, err)
+	}
+
+	var sum, n int
+	for _, r := range reviews {
+		if r.Score == 0 && len(r.Notes) == 0 {
+			continue
+		}
+		sum += clamp(r.Score, 0, 5)
+		n++
+		m.ReviewNotes = append(m.ReviewNotes, r.Notes...)
+	}
+	if n > 0 {
+		m.ReviewScore = roundDiv(sum, n)
+		m.Reviewed = true
+	}
+
+	return m, found
+}
+
+// packageTested reports whether a source directory has tests: next to it
+// (Go, JS), in its mirror under a test root (src/main -> src/test, src ->
+// tests), or in a test file named after it (tests/test_parser.py).
+func packageTested(dir string, testDirs map[string]bool, testBases []string) bool {
+	if testDirs[dir] {
+		return true
+	}
+	for _, mirror := range []string{
+		strings.Replace(dir, "/main/", "/test/", 1),
+		strings.Replace(dir, "src/main", "src/test", 1),
+		"tests/" + strings.TrimPrefix(dir, "src/"),
+		"test/" + strings.TrimPrefix(dir, "src/"),
+	} {
+		if mirror != dir && testDirs[mirror] {
+			return true
+		}
+	}
+	name := strings.ToLower(path.Base(dir))
+	if name == "." || name == "src" {
+		return false
+	}
+	for _, b := range testBases {
+		if namesDir(b, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// namesDir reports whether a test file base name has the directory name as
+// whole tokens, as in test_parser.py, parser_test.go, parser.spec.ts or
+// parsertest.java, so short names such as db do not match test_dbus.py.
+func namesDir(base, name string) bool {
+	notAlnum := func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }
+	tokens := strings.FieldsFunc(base, notAlnum)
+	want := strings.FieldsFunc(name, notAlnum)
+	if len(want) == 0 {
+		return false
+	}
+	for i := 0; i+len(want) <= len(tokens); i++ {
+		if slices.Equal(tokens[i:i+len(want)], want) {
+			return true
+		}
+	}
+	if len(want) == 1 {
+		for _, t := range tokens {
+			if t == "test"+name || t == name+"test" || t == name+"tests" || t == name+"spec" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isCompositeLit(e ast.Expr) bool {
+	_, ok := e.(*ast.CompositeLit)
+	return ok
+}
+
+// tableDrivenTests counts Test functions that range over a slice or map of
+// struct cases, the idiomatic Go table-driven layout.
+func tableDrivenTests(filename string, src []byte) int {
+	f, err := parser.ParseFile(token.NewFileSet(), filename, src, 0)
+	if err != nil {
+		return 0
+	}
+
+	n := 0
+	for _, decl := range f.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Body == nil || !strings.HasPrefix(fd.Name.Name, "Test") {
+			continue
+		}
+
+		var table, loop bool
+		ast.Inspect(fd.Body, func(node ast.Node) bool {
+			switch x := node.(type) {
+			case *ast.CompositeLit:
+				switch t := x.Type.(type) {
+				case *ast.ArrayType:
+					_, isStruct := t.Elt.(*ast.StructType)
+					table = table || isStruct || len(x.Elts) > 1 && isCompositeLit(x.Elts[0])
+				case *ast.MapType:
+					_, isStruct := t.Value.(*ast.StructType)
+					table = table || isStruct
+				}
+			case *ast.RangeStmt:
+				loop = true
+			}
+			return !(table && loop)
+		})
+		if table && loop {
+			n++
+		}
+	}
+	return n
+}
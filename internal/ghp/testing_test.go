@@ -0,0 +1,33 @@
+package ghp
+
+import "testing"
+
+func TestPackageTested(t *testing.T) {
+	testDirs := map[string]bool{"pkg/cache": true, "src/test/java/com/acme/web": true, "tests/io": true}
+	tests := []struct {
+		dir   string
+		bases []string
+		want  bool
+	}{
+		{"pkg/cache", nil, true},
+		{"src/main/java/com/acme/web", nil, true},
+		{"src/io", nil, true},
+		{"app/parser", []string{"test_parser.py"}, true},
+		{"app/parser", []string{"parser_test.go"}, true},
+		{"app/parser", []string{"parser.test.ts"}, true},
+		{"app/parser", []string{"parser.spec.js"}, true},
+		{"app/parser", []string{"parsertest.java"}, true},
+		{"app/db", []string{"test_dbus.py", "test_db_utils.py"}, true},
+		{"app/db", []string{"test_dbus.py"}, false},
+		{"app/api", []string{"test_capital.py", "rapid_test.go"}, false},
+		{"app/ui", []string{"build_test.go", "quick.spec.ts"}, false},
+		{"app/http_client", []string{"test_http_client.py"}, true},
+		{"app/http_client", []string{"test_http.py"}, false},
+		{"src", []string{"src_test.go"}, false},
+	}
+	for _, tt := range tests {
+		if got := packageTested(tt.dir, testDirs, tt.bases); got != tt.want {
+			t.Errorf("packageTested(%q, %v) = %v, want %v", tt.dir, tt.bases, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package ghp
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenEstimator estimates how many model tokens a text takes. None of the
+// implementations is a real tokenizer: they approximate the vocabulary of a
+// model family from character counts and overshoot by estimateMargin, so
+// budgets built on them err on the small side.
+type tokenEstimator interface {
+	Estimate(s string) int
+}
+
+// estimateMargin covers the error of the estimators on code, which is
+// within about 10% of the real tokenizers.
+const estimateMargin = 1.1
+
+func withMargin(n int) int {
+	return int(math.Round(float64(n) * estimateMargin))
+}
+
+// gptPieceRe is the cl100k/o200k pre-tokenization split, minus the lookahead
+// Go regexp does not support. BPE merges never cross these pieces.
+var gptPieceRe = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\pL\pN]?\pL+|\pN{1,3}| ?[^\s\pL\pN]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+// bpeEstimator approximates a GPT BPE vocabulary: pieces up to wordRunes
+// long are usually one token, longer ones split every runesPerToken runes.
+type bpeEstimator struct {
+	wordRunes     int
+	runesPerToken float64
+}
+
+func (e bpeEstimator) Estimate(s string) int {
+	n := 0
+	for _, p := range gptPieceRe.FindAllString(s, -1) {
+		l := utf8.RuneCountInString(p)
+		if l <= e.wordRunes {
+			n++
+			continue
+		}
+		n += int(math.Ceil(float64(l) / e.runesPerToken))
+	}
+	return withMargin(n)
+}
+
+// sentencePieceEstimator approximates SentencePiece vocabularies (Gemini),
+// which average about four characters per token on source code.
+type sentencePieceEstimator struct {
+	runesPerToken float64
+}
+
+func (e sentencePieceEstimator) Estimate(s string) int {
+	return withMargin(int(math.Ceil(float64(utf8.RuneCountInString(s)) / e.runesPerToken)))
+}
+
+// newTokenEstimator picks the estimator for the configured model family.
+func newTokenEstimator(provider, model string) tokenEstimator {
+	m := strings.ToLower(model)
+	switch {
+	case provider == "gemini", strings.HasPrefix(m, "gemini"):
+		return sentencePieceEstimator{runesPerToken: 4}
+	case strings.HasPrefix(m, "gpt-4o"), strings.HasPrefix(m, "gpt-4.1"), strings.HasPrefix(m, "gpt-5"),
+		strings.HasPrefix(m, "o1"), strings.HasPrefix(m, "o3"), strings.HasPrefix(m, "o4"):
+		return bpeEstimator{wordRunes: 10, runesPerToken: 4.4} // o200k_base
+	default:
+		return bpeEstimator{wordRunes: 8, runesPerToken: 4} // cl100k_base
+	}
+}
@@ -1,6 +1,9 @@
 package ghp
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 type RepoTarget struct {
 	Owner         string
@@ -9,6 +12,19 @@ type RepoTarget struct {
 	Stars         int
 	Pinned        bool
 	Language      string
+	Private       bool
+}
+
+type TreeEntry struct {
+	Path string
+	Size int
+}
+
+// ExcludedFile is a file left out of the review because it was generated,
+// vendored or minified rather than written by the candidate.
+type ExcludedFile struct {
+	Path   string
+	Reason string
 }
 
 type FileChunk struct {
@@ -17,23 +33,71 @@ type FileChunk struct {
 	EndLine   int
 	Content   string
 	Language  string
+	Symbol    string
+}
+
+type CommitInfo struct {
+	SHA       string
+	Message   string
+	Author    string
+	Date      time.Time
+	Additions int
+	Deletions int
 }
 
+type HistoryMetrics struct {
+	Commits           int
+	CommitsPerWeek    float64
+	ConventionalRatio float64
+	MessageQuality    int  // 0-5, LLM-rated over a sample of commit subjects
+	MessageRated      bool // False when the rating call failed
+	MessageNotes      []string
+	AvgChangeSize     float64
+	RecentCommits     int // commits in the last 90 days
+	DaysSinceLast     int
+}
+
+// ChunkScore holds the rubric dimension scores of a chunk, keyed by
+// dimension, plus the notes and citations behind them.
 type ChunkScore struct {
-	Readability int      `json:"readability"`
-	Design      int      `json:"design"`
-	Testing     int      `json:"testing"`
-	Maintain    int      `json:"maintainability"`
-	Idiomatic   int      `json:"idiomatic"`
-	Security    int      `json:"security"`
-	Notes       []string `json:"notes"`
-	Citations   []struct {
+	Scores    map[string]float64 `json:"scores"`
+	Notes     []Note             `json:"notes"`
+	Citations []struct {
 		File   string `json:"file"`
 		Lines  string `json:"lines"`
 		Reason string `json:"reason"`
 	} `json:"citations"`
 }
 
+// UnmarshalJSON also accepts the scores as top-level numeric fields,
+// the flat shape models fall back to.
+func (cs *ChunkScore) UnmarshalJSON(data []byte) error {
+	type plain ChunkScore
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*cs = ChunkScore(p)
+	if len(cs.Scores) > 0 {
+		return nil
+	}
+
+	var flat map[string]json.RawMessage
+	if err := json.Unmarshal(data, &flat); err != nil {
+		return nil
+	}
+	for k, raw := range flat {
+		var v float64
+		if json.Unmarshal(raw, &v) == nil {
+			if cs.Scores == nil {
+				cs.Scores = map[string]float64{}
+			}
+			cs.Scores[k] = v
+		}
+	}
+	return nil
+}
+
 type ArchStrength struct {
 	Point         string `json:"point"`
 	Justification string `json:"justification"`
@@ -82,14 +146,40 @@ func (ac *ArchConsideration) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// ReportMeta records how a report was produced, so a run can be reproduced.
+type ReportMeta struct {
+	Sampling string
+	Seed     int64
+	Formula  string
+	Rubric   Rubric
+	Prompts  []*Prompt
+	// Calibration describes the calibration applied, Estimate is the
+	// profile level; both empty without a calibration.
+	Calibration string
+	Estimate    *Calibrated
+}
+
 type RepoResult struct {
 	Repo               RepoTarget
+	SHA                string // Commit the repo was evaluated at
+	Class              RepoClass
 	Score              int
-	Strengths          []string
-	Risks              []string
+	Dimensions         map[string]float64 // 0-5 per dimension, aggregated like Score
+	Confidence         Confidence
+	Calibrated         *Calibrated
+	Strengths          []Note
+	Risks              []Note
 	ArchStrengths      []ArchStrength
 	ArchConsiderations []ArchConsideration
 	Samples            []struct{ URL, Note string }
+	History            HistoryMetrics
+	Testing            TestingMetrics
+	Docs               DocsMetrics
+	Health             RepoHealth
+	Deps               DependencyReport
+	Excluded           []ExcludedFile
+	Redactions         []Redaction
+	Static             StaticMetrics
 	Files              int
 	Chunks             int
 }
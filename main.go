@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"embed"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -12,10 +13,15 @@ import (
 	"github.com/adrianpk/ghp/internal/ghp"
 )
 
-//go:embed all:prompts
+//go:embed all:prompts all:rubrics
 var embeddedFS embed.FS
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "calibrate" {
+		calibrate(os.Args[2:])
+		return
+	}
+
 	cfgPath := flag.String("config", "./config.yml", "path to YAML config")
 	user := flag.String("user", "", "GitHub username/handle")
 	provider := flag.String("provider", "", "AI provider: openai or gemini")
@@ -24,39 +30,80 @@ func main() {
 		log.Fatal("missing --user")
 	}
 
-	cfg, err := ghp.LoadConfig(*cfgPath)
+	cfg, svc := setup(*cfgPath, *provider)
+
+	if err := os.MkdirAll(cfg.App.OutDir, 0o755); err != nil {
+		log.Fatalf("mkdir out: %v", err)
+	}
+
+	ctx := context.Background()
+	html, err := svc.Submit(ctx, *user)
 	if err != nil {
-		log.Fatalf("config: %v", err)
+		log.Fatalf("submit: %v", err)
 	}
 
-	if *provider != "" {
-		cfg.LLM.Provider = *provider
+	out := filepath.Join(cfg.App.OutDir, fmt.Sprintf("profile-%s.html", *user))
+	if err := os.WriteFile(out, []byte(html), 0o644); err != nil {
+		log.Fatalf("write: %v", err)
 	}
 
-	if err := os.MkdirAll(cfg.App.OutDir, 0o755); err != nil {
-		log.Fatalf("mkdir out: %v", err)
+	fmt.Println("Report:", out)
+}
+
+// calibrate runs the benchmark set and writes the calibration file that
+// app.calibration points reports to.
+func calibrate(args []string) {
+	fs := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	cfgPath := fs.String("config", "./config.yml", "path to YAML config")
+	provider := fs.String("provider", "", "AI provider: openai or gemini")
+	benchmarks := fs.String("benchmarks", "./config/benchmarks.yml", "path to the benchmark set")
+	out := fs.String("out", "./config/calibration.json", "where to write the calibration")
+	fs.Parse(args)
+
+	set, err := ghp.LoadBenchmarkSet(*benchmarks)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("benchmarks: %v; copy config/benchmarks.example.yml to %s and list repos with agreed levels", err, *benchmarks)
+	}
+	if err != nil {
+		log.Fatalf("benchmarks: %v", err)
 	}
 
-	llmClient, err := ghp.NewLLMClient(cfg)
+	_, svc := setup(*cfgPath, *provider)
+
+	cal, err := svc.Calibrate(context.Background(), set)
 	if err != nil {
-		log.Fatalf("llm: %v", err)
+		log.Fatalf("calibrate: %v", err)
 	}
 
-	svc, err := ghp.NewService(cfg, llmClient, embeddedFS)
+	if err := cal.Save(*out); err != nil {
+		log.Fatalf("write: %v", err)
+	}
+
+	for i, l := range cal.Levels[1:] {
+		fmt.Printf("%s from %.1f\n", l, cal.Thresholds[i])
+	}
+	fmt.Println("Calibration:", *out, "version", cal.Version)
+}
+
+func setup(cfgPath, provider string) (*ghp.Config, ghp.Service) {
+	cfg, err := ghp.LoadConfig(cfgPath)
 	if err != nil {
-		log.Fatalf("service: %v", err)
+		log.Fatalf("config: %v", err)
 	}
 
-	ctx := context.Background()
-	html, err := svc.Submit(ctx, *user)
+	if provider != "" {
+		cfg.LLM.Provider = provider
+	}
+
+	llmClient, err := ghp.NewLLMClient(cfg)
 	if err != nil {
-		log.Fatalf("submit: %v", err)
+		log.Fatalf("llm: %v", err)
 	}
 
-	out := filepath.Join(cfg.App.OutDir, fmt.Sprintf("profile-%s.html", *user))
-	if err := os.WriteFile(out, []byte(html), 0o644); err != nil {
-		log.Fatalf("write: %v", err)
+	svc, err := ghp.NewService(cfg, llmClient, embeddedFS)
+	if err != nil {
+		log.Fatalf("service: %v", err)
 	}
 
-	fmt.Println("Report:", out)
+	return cfg, svc
 }